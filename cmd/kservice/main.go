@@ -3,8 +3,10 @@ package main
 import (
 	"net/http"
 
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
 	"github.com/jinghzhu/kservice/pkg/api/v1/router"
 	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/controller"
 	"github.com/jinghzhu/kservice/pkg/logger"
 )
 
@@ -14,8 +16,23 @@ func init() {
 }
 
 func main() {
+	if err := apitypes.CheckDefaultClients(); err != nil {
+		panic(err)
+	}
 	g := config.GetConfig()
 	logger.InfoFields("Start kservice", logger.Fields{"Config": g})
+	go controller.LabelLegacyWorkers(config.ContextRoot, g)
+	if g.WorkerControllerEnabled {
+		go controller.NewWorkerController(g).Run(config.ContextRoot, 2)
+	}
+	go controller.NewWorkflowController(g).Run(config.ContextRoot)
+	go controller.NewDelayedController(g).Run(config.ContextRoot)
+	go controller.NewRetryController(g).Run(config.ContextRoot)
+	go controller.NewTimeoutController(g).Run(config.ContextRoot)
+	go controller.NewPreemptionController(g).Run(config.ContextRoot)
+	if g.IsQueueEnabled() {
+		go controller.NewQueueController(g).Run(config.ContextRoot)
+	}
 	r := router.DefaultRouter()
 	logger.Error(http.ListenAndServe(g.ListenAddress, r))
 }
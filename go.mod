@@ -7,9 +7,11 @@ require (
 	github.com/go-openapi/runtime v0.19.24
 	github.com/google/uuid v1.1.2
 	github.com/gorilla/mux v1.8.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/jinghzhu/goutils v0.0.0-20190928081314-4579c699c136
 	github.com/jinghzhu/kutils v0.0.0-20201206015721-1b9529ca590a
 	github.com/sirupsen/logrus v1.7.0
 	k8s.io/api v0.18.12
 	k8s.io/apimachinery v0.18.12
+	k8s.io/client-go v0.18.12
 )
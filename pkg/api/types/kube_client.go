@@ -0,0 +1,56 @@
+package types
+
+import (
+	"github.com/jinghzhu/kservice/pkg/config"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func init() {
+	if err := initDefaultKubeClient(); err != nil && initErr == nil {
+		initErr = err
+	}
+}
+
+func initDefaultKubeClient() error {
+	restConfig, err := clientcmd.BuildConfigFromFlags("", config.GetConfig().Kubeconfig)
+	if err != nil {
+		return err
+	}
+	c, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	dc, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	defaultRestConfig, defaultKubeClient, defaultDynClient = restConfig, c, dc
+
+	return nil
+}
+
+// CheckDefaultClients returns the error to create the default clients when the package is loaded. The error is kept
+// instead of panicking so that the package can be loaded without Kubernetes, such as in unit tests. The server
+// should check it before using the clients.
+func CheckDefaultClients() error {
+	return initErr
+}
+
+// DefaultKubeClient returns the default Kubernetes clientset. It's used for the APIs which the Pod client doesn't
+// provide, such as watching or streaming.
+func DefaultKubeClient() *kubernetes.Clientset {
+	return defaultKubeClient
+}
+
+// DefaultRestConfig returns the REST config of the default Kubernetes clientset.
+func DefaultRestConfig() *rest.Config {
+	return defaultRestConfig
+}
+
+// DefaultDynamicClient returns the default dynamic client. It's used for the custom resources.
+func DefaultDynamicClient() dynamic.Interface {
+	return defaultDynClient
+}
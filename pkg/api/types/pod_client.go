@@ -6,15 +6,19 @@ import (
 )
 
 func init() {
-	initDefaultPodClient()
+	if err := initDefaultPodClient(); err != nil && initErr == nil {
+		initErr = err
+	}
 }
 
-func initDefaultPodClient() {
+func initDefaultPodClient() error {
 	c, err := pod.New(config.ContextRoot, "", config.GetConfig().Kubeconfig)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	defaultPodClient = c
+
+	return nil
 }
 
 // DefaultPodClient returns the default Pod client.
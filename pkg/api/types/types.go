@@ -4,6 +4,10 @@ import (
 	"context"
 
 	"github.com/jinghzhu/kutils/pod"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 const (
@@ -18,15 +22,158 @@ const (
 	WorkerStatusRunning            string = "Running"
 	WorkerStatusTerminated         string = "Terminated"
 	WorkerStatusUnknown            string = "Unknown"
+	WorkerStatusQueued             string = "Queued"
 	ContainerStatusReasonCompleted string = "Completed"
 
+	JobStatusPending  string = "Pending"
+	JobStatusRunning  string = "Running"
+	JobStatusComplete string = "Complete"
+	JobStatusFailed   string = "Failed"
+	JobStatusQueued   string = "Queued"
+	// WorkerStatusReasonCancelled is the reason of worker which is cancelled by user.
+	WorkerStatusReasonCancelled string = "Cancelled"
+	// WorkerStatusReasonPreempted is the reason of worker which is preempted by the worker with higher priority.
+	WorkerStatusReasonPreempted string = "Preempted"
+	// WorkerStatusReasonTimedOut is the reason of worker which exceeds its maxRuntime or maxPending.
+	WorkerStatusReasonTimedOut string = "TimedOut"
+
+	StreamMessageStdin  string = "stdin"
+	StreamMessageStdout string = "stdout"
+	StreamMessageStderr string = "stderr"
+	StreamMessageExit   string = "exit"
+	StreamMessageError  string = "error"
+	StreamMessageResize string = "resize"
+
+	// LabelManagedWorker is the label of every worker Pod created by kservice whose value is ManagedWorkerValue.
+	// Only the Pods with it are listed, counted by the admission queue and handled on preemption.
+	LabelManagedWorker string = "kservice/managed-worker"
+	ManagedWorkerValue string = "true"
+	// LabelJobPod is the label of the Pods of Job created by kservice whose value is ManagedWorkerValue. They aren't
+	// workers but are counted by the admission queue.
+	LabelJobPod string = "kservice/job-pod"
+	// LabelDebugFor is the label of debug Pod whose value is the name of worker being debugged.
+	LabelDebugFor string = "kservice/debug-for"
+	// LabelWorker is the label of Pod created for Worker custom resource whose value is the name of Worker.
+	LabelWorker string = "kservice/worker"
+	// LabelArray and LabelArrayIndex are the labels of Pod created for worker array whose values are the array ID
+	// and the index of the Pod in array.
+	LabelArray      string = "kservice/array"
+	LabelArrayIndex string = "kservice/array-index"
+	// AnnotationArraySize and AnnotationArrayParams are the annotations of Pod created for worker array whose values
+	// are the number of workers in array and the parameter values of the Pod in JSON.
+	AnnotationArraySize   string = "kservice/array-size"
+	AnnotationArrayParams string = "kservice/array-params"
+	// LabelWorkflow and LabelWorkflowStep are the labels of workflow ConfigMap and step Pods whose values are the
+	// workflow ID and the step name.
+	LabelWorkflow     string = "kservice/workflow"
+	LabelWorkflowStep string = "kservice/workflow-step"
+	// LabelWorkflowPhase is the label of workflow ConfigMap whose value is the workflow phase, so the finished
+	// workflows can be filtered out by selector.
+	LabelWorkflowPhase string = "kservice/workflow-phase"
+
+	// LabelSchedule is the label of Job created by schedule whose value is the schedule ID.
+	LabelSchedule string = "kservice/schedule"
+
+	// LabelService is the label of Deployment, Service and Pods of long-running service whose value is the service
+	// ID.
+	LabelService string = "kservice/service"
+	// AnnotationRestartedAt is the Pod template annotation which triggers the rollout of Deployment.
+	AnnotationRestartedAt string = "kubectl.kubernetes.io/restartedAt"
+
+	// LabelDelayedWorker is the label of ConfigMap which persists the delayed worker whose value is the worker ID.
+	LabelDelayedWorker string = "kservice/delayed-worker"
+	// DelayedPhaseScheduled, DelayedPhaseFiring and DelayedPhaseFailed are the phases of delayed worker. It's
+	// Firing after a replica of kservice claims it and is deleted after its Pod is created.
+	DelayedPhaseScheduled string = "Scheduled"
+	DelayedPhaseFiring    string = "Firing"
+	DelayedPhaseFailed    string = "Failed"
+
+	// LabelQueuedWorker is the label of ConfigMap which holds the worker in admission queue whose value is the
+	// worker ID. QueuePhaseQueued, QueuePhaseDispatching and QueuePhaseFailed are the phases of queued worker. It's
+	// Dispatching after a replica of kservice claims it and is deleted after its Pod is created.
+	LabelQueuedWorker     string = "kservice/queued-worker"
+	QueuePhaseQueued      string = "Queued"
+	QueuePhaseDispatching string = "Dispatching"
+	QueuePhaseFailed      string = "Failed"
+	// QueuePositionFront and QueuePositionBack move the queued worker among the ones with the same priority.
+	QueuePositionFront string = "front"
+	QueuePositionBack  string = "back"
+	// AnnotationPriority is the annotation of Pod whose value is the priority of worker.
+	AnnotationPriority string = "kservice/priority"
+
+	// AnnotationPreemptedBy is the annotation of preempted Pod whose value is the ID of worker preempting it.
+	// LabelPreemptedWorker is the label of ConfigMap which keeps the status of preempted worker after its Pod is
+	// deleted whose value is the worker ID.
+	AnnotationPreemptedBy string = "kservice/preempted-by"
+	LabelPreemptedWorker  string = "kservice/preempted-worker"
+
+	// LabelRetryOf is the label of every attempt Pod of worker with retry policy whose value is the logical worker
+	// ID, which is the name of the first attempt.
+	LabelRetryOf string = "kservice/retry-of"
+	// AnnotationRetryPolicy, AnnotationAttempt and AnnotationRetried are the annotations of attempt Pod whose values
+	// are the retry policy in JSON, the attempt number and the name of the next attempt.
+	AnnotationRetryPolicy string = "kservice/retry-policy"
+	AnnotationAttempt     string = "kservice/attempt"
+	AnnotationRetried     string = "kservice/retried"
+	// AnnotationFailReason is the annotation of Pod which kservice fails on purpose, such as the Pod stuck in
+	// pulling image. Its value is the reason reported instead of DeadlineExceeded.
+	AnnotationFailReason string = "kservice/fail-reason"
+	// AnnotationActiveDeadline is the original activeDeadlineSeconds of the Pod which kservice fails on purpose.
+	AnnotationActiveDeadline string = "kservice/active-deadline-seconds"
+	// AnnotationRerunOf and AnnotationRerunOrigin are the annotations of rerun Pod whose values are the name of the
+	// worker it reruns and the name of the original worker of all reruns.
+	AnnotationRerunOf     string = "kservice/rerun-of"
+	AnnotationRerunOrigin string = "kservice/rerun-origin"
+	// LabelMaxPending is the label of Pod with maxPending whose value is the seconds it can stay in Pending.
+	LabelMaxPending string = "kservice/max-pending"
+
+	WorkflowPhaseRunning   string = "Running"
+	WorkflowPhaseSucceeded string = "Succeeded"
+	WorkflowPhaseFailed    string = "Failed"
+	StepPhasePending       string = "Pending"
+	StepPhaseRunning       string = "Running"
+	StepPhaseSucceeded     string = "Succeeded"
+	StepPhaseFailed        string = "Failed"
+	StepPhaseSkipped       string = "Skipped"
+	// StepWhenSucceeded, StepWhenFailed and StepWhenAlways are the run conditions of step based on its dependencies.
+	StepWhenSucceeded string = "Succeeded"
+	StepWhenFailed    string = "Failed"
+	StepWhenAlways    string = "Always"
+
+	// WorkerCRDGroup, WorkerCRDVersion, WorkerCRDKind and WorkerCRDPlural identify the Worker custom resource.
+	WorkerCRDGroup   string = "kservice.jinghzhu.io"
+	WorkerCRDVersion string = "v1"
+	WorkerCRDKind    string = "Worker"
+	WorkerCRDPlural  string = "workers"
+	// FinalizerWorker is the finalizer of Worker which makes sure its Pod is deleted together.
+	FinalizerWorker string = "kservice.jinghzhu.io/pod-cleanup"
+
 	// MountTypeNFS is the name of NFS mount type.
 	MountTypeNFS string = "NFS"
 	// MountTypeKubeSecret is the name of kubesecret mount type.
 	MountTypeKubeSecret string = "secret"
+	// MountTypeConfigMap, MountTypePVC and MountTypeEmptyDir are the names of ConfigMap, PersistentVolumeClaim and
+	// emptyDir mount types.
+	MountTypeConfigMap string = "configMap"
+	MountTypePVC       string = "persistentVolumeClaim"
+	MountTypeEmptyDir  string = "emptyDir"
+	// ServiceAccountMountPath is where Kubernetes mounts the service account token, which isn't a worker mount.
+	ServiceAccountMountPath string = "/var/run/secrets/kubernetes.io/serviceaccount"
 )
 
 var (
-	ContextRoot      = context.Background()
-	defaultPodClient *pod.Client
+	ContextRoot       = context.Background()
+	defaultPodClient  *pod.Client
+	defaultKubeClient *kubernetes.Clientset
+	defaultRestConfig *rest.Config
+	defaultDynClient  dynamic.Interface
+	// initErr is the first error to create the default clients.
+	initErr error
+
+	// WorkerResource is the resource of Worker custom resource.
+	WorkerResource = schema.GroupVersionResource{
+		Group:    WorkerCRDGroup,
+		Version:  WorkerCRDVersion,
+		Resource: WorkerCRDPlural,
+	}
 )
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
@@ -18,30 +19,135 @@ import (
 	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // InitWorkerPod inits a default WorkPod spec.
 func InitWorkerPod(ctx context.Context, jsonBody io.ReadCloser) (*v1types.WorkerPod, error) {
+	wp := newDefaultWorkerPod(ctx)
+	err := json.NewDecoder(jsonBody).Decode(&wp)
+
+	return wp, completeWorkerPod(ctx, wp, err)
+}
+
+// InitWorkerJob inits a default WorkerJob spec.
+func InitWorkerJob(ctx context.Context, jsonBody io.ReadCloser) (*v1types.WorkerJob, error) {
+	wj := &v1types.WorkerJob{WorkerPod: *newDefaultWorkerPod(ctx)}
+	err := json.NewDecoder(jsonBody).Decode(wj)
+	if err = completeWorkerPod(ctx, &wj.WorkerPod, err); err != nil {
+		return wj, err
+	}
+
+	return wj, rejectPodOnlyFields(&wj.WorkerPod)
+}
+
+// newDefaultWorkerPod returns the default WorkerPod whose container name is suffixed with the request ID.
+func newDefaultWorkerPod(ctx context.Context) *v1types.WorkerPod {
 	g := config.GetConfig()
 	wp := v1types.DefaultWorkerPod(g)
-	id := ctx.Value(apitypes.LogCtxID).(uuid.UUID)
-	idArr := strings.Split(id.String(), "-")
-	wp.Name = wp.Name + idArr[0]
+	wp.Name = wp.Name + requestIDPrefix(ctx)
 	logger.InfoFields("This default", logger.Fields{
 		apitypes.LogCtxID:  ctx.Value(apitypes.LogCtxID),
 		"Container-prefix": wp.Name,
 	})
 
-	err := json.NewDecoder(jsonBody).Decode(&wp)
-	wp.Prefix = wp.Prefix + "-" + idArr[0] + "-"
+	return wp
+}
+
+// completeWorkerPod suffixes the name prefix with the request ID and checks the mandatory parameters after
+// WorkerPod is decoded. The decode error is returned directly.
+func completeWorkerPod(ctx context.Context, wp *v1types.WorkerPod, err error) error {
+	wp.Prefix = wp.Prefix + "-" + requestIDPrefix(ctx) + "-"
 	if err != nil {
-		return wp, err
+		return err
 	}
 	if wp.Cmd == nil {
-		return wp, errors.New("cmd is a mandatory parameter")
+		return errors.New("cmd is a mandatory parameter")
+	}
+	if wp.RunAt != nil && wp.NotBefore != nil {
+		return errors.New("runAt and notBefore can't be set together")
+	}
+	if wp.NotBefore != nil {
+		wp.RunAt, wp.NotBefore = wp.NotBefore, nil
+	}
+	// The username comes from the request body and isn't authenticated, so the policy is advisory.
+	class := config.GetConfig().PriorityClassOf(wp.Priority)
+	if class != nil && !class.IsAllowed(wp.UserInfo.UserName) {
+		return fmt.Errorf("user %q is not allowed to use priority %d", wp.UserInfo.UserName, wp.Priority)
+	}
+	if err = validateMountNames(wp.Mounts); err != nil {
+		return err
+	}
+	if _, _, err = wp.TranslateMounts(); err != nil {
+		return err
+	}
+	if wp.MaxRuntime < 0 || wp.MaxPending < 0 {
+		return errors.New("maxRuntime and maxPending should not be negative")
+	}
+	if wp.RetryPolicy != nil {
+		return completeRetryPolicy(wp.RetryPolicy)
 	}
 
-	return wp, err
+	return err
+}
+
+// validateMountNames checks the names of mounts, which name the volumes of Pod. The mount without name has the
+// generated one.
+func validateMountNames(mounts []v1types.Mount) error {
+	names := make(map[string]bool, len(mounts))
+	for _, mount := range mounts {
+		if mount.Name == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Label(mount.Name); len(errs) > 0 {
+			return fmt.Errorf("mount name %s is invalid: %s", mount.Name, strings.Join(errs, "; "))
+		}
+		if names[mount.Name] {
+			return fmt.Errorf("mount name %s is duplicated", mount.Name)
+		}
+		names[mount.Name] = true
+	}
+
+	return nil
+}
+
+// rejectPodOnlyFields returns error if the WorkerPod has the fields which are only honored by POST /pods, so that
+// the other kinds of worker don't ignore them silently.
+func rejectPodOnlyFields(wp *v1types.WorkerPod) error {
+	if wp.RunAt != nil || wp.NotBefore != nil {
+		return errors.New("runAt and notBefore are only supported by POST /pods")
+	}
+	if wp.RetryPolicy != nil {
+		return errors.New("retryPolicy is only supported by POST /pods")
+	}
+
+	return nil
+}
+
+// namePod sets the name of Pod from its generated name if it has no name yet, so that the Pod created later has a
+// known ID.
+func namePod(pod *corev1.Pod) {
+	if pod.Name == "" {
+		pod.Name = pod.GenerateName + uuid.New().String()[:5]
+		pod.GenerateName = ""
+	}
+}
+
+// ManagedWorkerSelector restricts the label selector to the worker Pods created by kservice.
+func ManagedWorkerSelector(selector string) string {
+	managed := apitypes.LabelManagedWorker + "=" + apitypes.ManagedWorkerValue
+	if selector == "" {
+		return managed
+	}
+
+	return managed + "," + selector
+}
+
+// requestIDPrefix returns the first part of the request ID.
+func requestIDPrefix(ctx context.Context) string {
+	id := ctx.Value(apitypes.LogCtxID).(uuid.UUID)
+
+	return strings.Split(id.String(), "-")[0]
 }
 
 func setPodContainer(wp *types.WorkerPod, mounts []corev1.VolumeMount) (container corev1.Container, err error) {
@@ -82,14 +188,29 @@ func setPodContainer(wp *types.WorkerPod, mounts []corev1.VolumeMount) (containe
 // TranslateWorkerPodToPod translates the WorkerPod to Kubernetes Pod.
 func TranslateWorkerPodToPod(ctx context.Context, wp *types.WorkerPod) (*corev1.Pod, error) {
 	g := config.GetConfig()
-	mounts, vols := wp.TranslateMounts()
+	mounts, vols, err := wp.TranslateMounts()
+	if err != nil {
+		return nil, err
+	}
 	container, err := setPodContainer(wp, mounts)
+	// The labels and annotations are copied since the WorkerPod may be shared, such as the template of array.
+	labels := map[string]string{apitypes.LabelManagedWorker: apitypes.ManagedWorkerValue}
+	for k, v := range wp.Labels {
+		labels[k] = v
+	}
+	var annotations map[string]string
+	if len(wp.Annotations) > 0 {
+		annotations = make(map[string]string, len(wp.Annotations))
+		for k, v := range wp.Annotations {
+			annotations[k] = v
+		}
+	}
 	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: wp.Prefix,
 			Namespace:    wp.Namespace,
-			Labels:       wp.Labels,
-			Annotations:  wp.Annotations,
+			Labels:       labels,
+			Annotations:  annotations,
 		},
 		Spec: corev1.PodSpec{
 			SecurityContext: &corev1.PodSecurityContext{},
@@ -99,6 +220,22 @@ func TranslateWorkerPodToPod(ctx context.Context, wp *types.WorkerPod) (*corev1.
 			Volumes:         vols,
 		},
 	}
+	if wp.MaxRuntime > 0 {
+		maxRuntime := wp.MaxRuntime
+		pod.Spec.ActiveDeadlineSeconds = &maxRuntime
+	}
+	if class := g.PriorityClassOf(wp.Priority); class != nil {
+		pod.Spec.PriorityClassName = class.Name
+	}
+	if wp.Priority != 0 {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[apitypes.AnnotationPriority] = strconv.Itoa(int(wp.Priority))
+	}
+	if wp.MaxPending > 0 {
+		pod.Labels[apitypes.LabelMaxPending] = strconv.FormatInt(wp.MaxPending, 10)
+	}
 	logger.InfoFields("Output kubeconfig name", logger.Fields{
 		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
 		"kubeconfig":                g.Kubeconfig,
@@ -116,6 +253,8 @@ func TranslateWorkerPodToPod(ctx context.Context, wp *types.WorkerPod) (*corev1.
 	return pod, err
 }
 
+// TranslatePodToWorkerPod translates the Pod back to WorkerPod. The timeouts, priority and retry policy are
+// recovered from the Pod spec, labels and annotations.
 func TranslatePodToWorkerPod(ctx context.Context, pod *corev1.Pod) *v1types.WorkerPod {
 	wp := &v1types.WorkerPod{
 		Env:              TranslatePodEnv(ctx, pod),
@@ -133,6 +272,24 @@ func TranslatePodToWorkerPod(ctx context.Context, pod *corev1.Pod) *v1types.Work
 	if len(image) > 1 {
 		wp.ImageVersion = image[1]
 	}
+	if sc := pod.Spec.Containers[0].SecurityContext; sc != nil && sc.ReadOnlyRootFilesystem != nil {
+		wp.ReadOnlyFS = *sc.ReadOnlyRootFilesystem
+	}
+	// The active deadline of Pod failed by kservice on purpose isn't the original one.
+	if deadline, ok := pod.Annotations[apitypes.AnnotationActiveDeadline]; ok {
+		wp.MaxRuntime, _ = strconv.ParseInt(deadline, 10, 64)
+	} else if pod.Spec.ActiveDeadlineSeconds != nil {
+		wp.MaxRuntime = *pod.Spec.ActiveDeadlineSeconds
+	}
+	if maxPending, ok := pod.Labels[apitypes.LabelMaxPending]; ok {
+		wp.MaxPending, _ = strconv.ParseInt(maxPending, 10, 64)
+	}
+	if priority, err := strconv.ParseInt(pod.Annotations[apitypes.AnnotationPriority], 10, 32); err == nil {
+		wp.Priority = int32(priority)
+	}
+	if _, ok := pod.Annotations[apitypes.AnnotationRetryPolicy]; ok {
+		wp.RetryPolicy, _ = PodRetryPolicy(pod)
+	}
 
 	return wp
 }
@@ -210,14 +367,173 @@ func TranslatePodMounts(ctx context.Context, pod *corev1.Pod) []v1types.Mount {
 				break
 			}
 		}
-		if volume.VolumeSource.NFS != nil {
-			mount.Server = volume.VolumeSource.NFS.Server
-			mount.Share = volume.VolumeSource.NFS.Path
-			mount.Type = apitypes.MountTypeNFS
-			mounts = append(mounts, *mount)
-		} else {
+		if !translateVolumeSource(volume.VolumeSource, mount) || volMount.MountPath == apitypes.ServiceAccountMountPath {
 			continue
 		}
+		mount.ReadOnly = volMount.ReadOnly
+		mount.SubPath = volMount.SubPath
+		mounts = append(mounts, *mount)
 	}
 	return mounts
 }
+
+// translateVolumeSource sets the type and source of Mount from the volume source. It returns false if the volume
+// isn't of any mount type.
+func translateVolumeSource(source corev1.VolumeSource, mount *v1types.Mount) bool {
+	switch {
+	case source.NFS != nil:
+		mount.Type = apitypes.MountTypeNFS
+		mount.Server = source.NFS.Server
+		mount.Share = source.NFS.Path
+	case source.Secret != nil:
+		mount.Type = apitypes.MountTypeKubeSecret
+		mount.Source = source.Secret.SecretName
+	case source.ConfigMap != nil:
+		mount.Type = apitypes.MountTypeConfigMap
+		mount.Source = source.ConfigMap.Name
+	case source.PersistentVolumeClaim != nil:
+		mount.Type = apitypes.MountTypePVC
+		mount.Source = source.PersistentVolumeClaim.ClaimName
+	case source.EmptyDir != nil:
+		mount.Type = apitypes.MountTypeEmptyDir
+		mount.Medium = string(source.EmptyDir.Medium)
+		if source.EmptyDir.SizeLimit != nil {
+			mount.SizeLimit = source.EmptyDir.SizeLimit.String()
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// TranslatePodStatus parses the Pod status into WorkerStatus. The top-level state comes from the worker
+// container, which is the first container in Pod spec.
+func TranslatePodStatus(pod *corev1.Pod) *v1types.WorkerStatus {
+	workerStatus := &v1types.WorkerStatus{
+		Reason:         pod.Status.Reason,
+		Message:        pod.Status.Message,
+		State:          apitypes.WorkerStatusUnknown,
+		Status:         string(pod.Status.Phase),
+		NodeName:       pod.Spec.NodeName,
+		PodIP:          pod.Status.PodIP,
+		Evicted:        pod.Status.Reason == reasonEvicted,
+		Conditions:     translatePodConditions(pod.Status.Conditions),
+		InitContainers: translateContainerStatuses(pod.Status.InitContainerStatuses),
+		Containers:     translateContainerStatuses(pod.Status.ContainerStatuses),
+	}
+	if pod.Status.StartTime != nil {
+		startTime := pod.Status.StartTime.Time
+		workerStatus.StartTime = &startTime
+	}
+
+	var workerContainer *v1types.ContainerStatus
+	for i := range workerStatus.Containers {
+		if len(pod.Spec.Containers) > 0 && workerStatus.Containers[i].Name == pod.Spec.Containers[0].Name {
+			workerContainer = &workerStatus.Containers[i]
+		}
+	}
+	if workerContainer == nil && len(workerStatus.Containers) > 0 {
+		workerContainer = &workerStatus.Containers[0]
+	}
+	if workerContainer != nil {
+		switch workerContainer.State {
+		case apitypes.WorkerStatusWaiting:
+			workerStatus.State = apitypes.WorkerStatusWaiting
+			workerStatus.Reason = workerContainer.Reason
+			workerStatus.Message = workerContainer.Message
+		case apitypes.WorkerStatusRunning:
+			workerStatus.State = apitypes.WorkerStatusRunning
+		case apitypes.WorkerStatusTerminated:
+			workerStatus.State = apitypes.WorkerStatusTerminated
+			workerStatus.Reason = workerContainer.Reason
+			workerStatus.Message = workerContainer.Message
+			workerStatus.ExitCode = workerContainer.ExitCode
+		}
+	}
+
+	allTerminated := len(workerStatus.Containers) > 0
+	for _, containerStatus := range append(workerStatus.InitContainers, workerStatus.Containers...) {
+		workerStatus.RestartCount += containerStatus.RestartCount
+		workerStatus.OOMKilled = workerStatus.OOMKilled || containerStatus.OOMKilled
+	}
+	for _, containerStatus := range workerStatus.Containers {
+		if containerStatus.FinishTime == nil {
+			allTerminated = false
+		} else if workerStatus.FinishTime == nil || containerStatus.FinishTime.After(*workerStatus.FinishTime) {
+			workerStatus.FinishTime = containerStatus.FinishTime
+		}
+	}
+	if !allTerminated {
+		workerStatus.FinishTime = nil
+	}
+	// The reason of Pod failed by kservice or its active deadline takes precedence over the container's.
+	if pod.Status.Phase == corev1.PodFailed {
+		if reason := pod.Annotations[apitypes.AnnotationFailReason]; reason != "" {
+			workerStatus.Reason = reason
+		} else if pod.Status.Reason == reasonDeadlineExceeded {
+			workerStatus.Reason = apitypes.WorkerStatusReasonTimedOut
+			workerStatus.Message = pod.Status.Message
+		}
+	}
+	if preemptor := pod.Annotations[apitypes.AnnotationPreemptedBy]; preemptor != "" {
+		workerStatus.Reason = apitypes.WorkerStatusReasonPreempted
+		workerStatus.Message = "Worker is preempted by " + preemptor
+		workerStatus.PreemptedBy = preemptor
+	}
+
+	return workerStatus
+}
+
+func translatePodConditions(conditions []corev1.PodCondition) []v1types.PodCondition {
+	podConditions := make([]v1types.PodCondition, 0, len(conditions))
+	for _, condition := range conditions {
+		podConditions = append(podConditions, v1types.PodCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time,
+		})
+	}
+
+	return podConditions
+}
+
+func translateContainerStatuses(statuses []corev1.ContainerStatus) []v1types.ContainerStatus {
+	containerStatuses := make([]v1types.ContainerStatus, 0, len(statuses))
+	for _, status := range statuses {
+		containerStatus := v1types.ContainerStatus{
+			Name:         status.Name,
+			State:        apitypes.WorkerStatusUnknown,
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+			Image:        status.Image,
+			ImageID:      status.ImageID,
+		}
+		if waiting := status.State.Waiting; waiting != nil {
+			containerStatus.State = apitypes.WorkerStatusWaiting
+			containerStatus.Reason = waiting.Reason
+			containerStatus.Message = waiting.Message
+		} else if running := status.State.Running; running != nil {
+			containerStatus.State = apitypes.WorkerStatusRunning
+			startTime := running.StartedAt.Time
+			containerStatus.StartTime = &startTime
+		} else if terminated := status.State.Terminated; terminated != nil {
+			containerStatus.State = apitypes.WorkerStatusTerminated
+			containerStatus.Reason = terminated.Reason
+			containerStatus.Message = terminated.Message
+			exitCode := terminated.ExitCode
+			containerStatus.ExitCode = &exitCode
+			startTime, finishTime := terminated.StartedAt.Time, terminated.FinishedAt.Time
+			containerStatus.StartTime, containerStatus.FinishTime = &startTime, &finishTime
+		}
+		// A container restarted after OOM kill is also reported.
+		lastTerminated := status.LastTerminationState.Terminated
+		containerStatus.OOMKilled = containerStatus.Reason == reasonOOMKilled ||
+			(lastTerminated != nil && lastTerminated.Reason == reasonOOMKilled)
+		containerStatuses = append(containerStatuses, containerStatus)
+	}
+
+	return containerStatuses
+}
@@ -0,0 +1,180 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// InitWorkerArray inits a default WorkerArray spec and validates the index range or parameter matrix.
+func InitWorkerArray(ctx context.Context, jsonBody io.ReadCloser) (*v1types.WorkerArray, error) {
+	wa := &v1types.WorkerArray{WorkerPod: *newDefaultWorkerPod(ctx)}
+	err := json.NewDecoder(jsonBody).Decode(wa)
+	if err = completeWorkerPod(ctx, &wa.WorkerPod, err); err != nil {
+		return wa, err
+	}
+	if err = rejectPodOnlyFields(&wa.WorkerPod); err != nil {
+		return wa, err
+	}
+	if (wa.IndexRange == nil) == (len(wa.Matrix) == 0) {
+		return wa, errors.New("either indexRange or matrix should be set")
+	}
+	size := 1
+	if wa.IndexRange != nil {
+		if wa.IndexRange.End < wa.IndexRange.Start {
+			return wa, errors.New("end of indexRange should not be less than start")
+		}
+		// The difference is taken in uint64 since it may overflow int.
+		if uint64(wa.IndexRange.End)-uint64(wa.IndexRange.Start) >= uint64(v1types.MaxArraySize) {
+			return wa, fmt.Errorf("array should have at most %d workers", v1types.MaxArraySize)
+		}
+		size = wa.IndexRange.End - wa.IndexRange.Start + 1
+	}
+	for name, values := range wa.Matrix {
+		if errs := validation.IsEnvVarName(name); len(errs) > 0 {
+			return wa, fmt.Errorf("matrix parameter %s is not a valid env name: %s", name, strings.Join(errs, "; "))
+		}
+		if len(values) == 0 {
+			return wa, fmt.Errorf("matrix parameter %s has no value", name)
+		}
+		size *= len(values)
+		if size > v1types.MaxArraySize {
+			break
+		}
+	}
+	if size > v1types.MaxArraySize {
+		return wa, fmt.Errorf("array should have at most %d workers", v1types.MaxArraySize)
+	}
+
+	return wa, nil
+}
+
+// TranslateWorkerArrayToPods translates the WorkerArray to the array ID and the Pods of array ordered by index.
+func TranslateWorkerArrayToPods(ctx context.Context, wa *v1types.WorkerArray) (string, []*corev1.Pod, error) {
+	arrayID := "array-" + requestIDPrefix(ctx)
+	indexes, params := arrayElements(wa)
+	size := strconv.Itoa(len(indexes))
+	pods := make([]*corev1.Pod, 0, len(indexes))
+	for i, index := range indexes {
+		wp := wa.WorkerPod
+		wp.Env = make(map[string]string)
+		for k, v := range wa.Env {
+			wp.Env[k] = v
+		}
+		wp.Labels = make(map[string]string)
+		for k, v := range wa.Labels {
+			wp.Labels[k] = v
+		}
+		wp.Annotations = make(map[string]string)
+		for k, v := range wa.Annotations {
+			wp.Annotations[k] = v
+		}
+		for k, v := range params[i] {
+			wp.Env[k] = v
+		}
+		wp.Env[v1types.EnvArrayID] = arrayID
+		wp.Env[v1types.EnvArrayIndex] = strconv.Itoa(index)
+		wp.Env[v1types.EnvArraySize] = size
+		wp.Labels[apitypes.LabelArray] = arrayID
+		wp.Labels[apitypes.LabelArrayIndex] = strconv.Itoa(index)
+		wp.Annotations[apitypes.AnnotationArraySize] = size
+		if len(params[i]) > 0 {
+			data, err := json.Marshal(params[i])
+			if err != nil {
+				return arrayID, nil, err
+			}
+			wp.Annotations[apitypes.AnnotationArrayParams] = string(data)
+		}
+		pod, err := TranslateWorkerPodToPod(ctx, &wp)
+		if err != nil {
+			return arrayID, nil, err
+		}
+		pods = append(pods, pod)
+	}
+
+	return arrayID, pods, nil
+}
+
+// arrayElements returns the index and parameter values of every worker of array. The combinations of matrix are
+// ordered by the parameter names, with the values of the last name changing fastest.
+func arrayElements(wa *v1types.WorkerArray) ([]int, []map[string]string) {
+	indexes, params := []int{}, []map[string]string{}
+	if wa.IndexRange != nil {
+		// Counting from start doesn't overflow even if end is the max int.
+		for i := 0; i <= wa.IndexRange.End-wa.IndexRange.Start; i++ {
+			indexes = append(indexes, wa.IndexRange.Start+i)
+			params = append(params, nil)
+		}
+		return indexes, params
+	}
+
+	names := make([]string, 0, len(wa.Matrix))
+	for name := range wa.Matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	params = []map[string]string{{}}
+	for _, name := range names {
+		combinations := make([]map[string]string, 0, len(params)*len(wa.Matrix[name]))
+		for _, param := range params {
+			for _, value := range wa.Matrix[name] {
+				combination := map[string]string{name: value}
+				for k, v := range param {
+					combination[k] = v
+				}
+				combinations = append(combinations, combination)
+			}
+		}
+		params = combinations
+	}
+	for index := range params {
+		indexes = append(indexes, index)
+	}
+
+	return indexes, params
+}
+
+// TranslateArrayStatus aggregates the status of the Pods of array into ArrayStatus.
+func TranslateArrayStatus(arrayID string, pods []corev1.Pod) *v1types.ArrayStatus {
+	arrayStatus := &v1types.ArrayStatus{
+		Id:       arrayID,
+		Elements: []v1types.ArrayElement{},
+	}
+	for i := range pods {
+		pod := &pods[i]
+		element := v1types.ArrayElement{WorkerSummary: TranslatePodSummary(pod)}
+		element.Index, _ = strconv.Atoi(pod.GetLabels()[apitypes.LabelArrayIndex])
+		if data, ok := pod.GetAnnotations()[apitypes.AnnotationArrayParams]; ok {
+			_ = json.Unmarshal([]byte(data), &element.Params)
+		}
+		if size, err := strconv.Atoi(pod.GetAnnotations()[apitypes.AnnotationArraySize]); err == nil {
+			arrayStatus.Size = size
+		}
+		switch pod.Status.Phase {
+		case corev1.PodPending:
+			arrayStatus.Pending++
+		case corev1.PodRunning:
+			arrayStatus.Running++
+		case corev1.PodSucceeded:
+			arrayStatus.Succeeded++
+		case corev1.PodFailed:
+			arrayStatus.Failed++
+		}
+		arrayStatus.Elements = append(arrayStatus.Elements, element)
+	}
+	sort.SliceStable(arrayStatus.Elements, func(i, j int) bool {
+		return arrayStatus.Elements[i].Index < arrayStatus.Elements[j].Index
+	})
+
+	return arrayStatus
+}
@@ -0,0 +1,100 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// delayedPodKey, delayedRunAtKey, delayedPhaseKey, delayedMessageKey and delayedClaimedAtKey are the keys of
+	// delayed worker ConfigMap data.
+	delayedPodKey       string = "pod"
+	delayedRunAtKey     string = "runAt"
+	delayedPhaseKey     string = "phase"
+	delayedMessageKey   string = "msg"
+	delayedClaimedAtKey string = "claimedAt"
+	// claimTimeout is the time after which the delayed or queued worker claimed by a replica can be claimed again,
+	// in case the replica exits before creating the Pod.
+	claimTimeout time.Duration = time.Minute
+)
+
+// TranslatePodToDelayedConfigMap names the Pod if it has no name yet and translates it to the ConfigMap which persists it until runAt.
+// The ConfigMap has the same name as the Pod.
+func TranslatePodToDelayedConfigMap(pod *corev1.Pod, runAt time.Time) (*corev1.ConfigMap, error) {
+	namePod(pod)
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels: map[string]string{
+				apitypes.LabelDelayedWorker: pod.Name,
+			},
+		},
+		Data: map[string]string{
+			delayedPodKey:   string(data),
+			delayedRunAtKey: runAt.UTC().Format(time.RFC3339),
+			delayedPhaseKey: apitypes.DelayedPhaseScheduled,
+		},
+	}
+
+	return cm, nil
+}
+
+// TranslateConfigMapToDelayedWorker parses the delayed worker and its Pod from the ConfigMap.
+func TranslateConfigMapToDelayedWorker(cm *corev1.ConfigMap) (*v1types.DelayedWorker, *corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal([]byte(cm.Data[delayedPodKey]), pod); err != nil {
+		return nil, nil, fmt.Errorf("invalid Pod of delayed worker %s: %v", cm.GetName(), err)
+	}
+	runAt, err := time.Parse(time.RFC3339, cm.Data[delayedRunAtKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid runAt of delayed worker %s: %v", cm.GetName(), err)
+	}
+	dw := &v1types.DelayedWorker{
+		Id:           cm.GetName(),
+		Namespace:    cm.GetNamespace(),
+		RunAt:        runAt,
+		Phase:        cm.Data[delayedPhaseKey],
+		Message:      cm.Data[delayedMessageKey],
+		CreationTime: cm.GetCreationTimestamp().Time,
+	}
+	if len(pod.Spec.Containers) > 0 {
+		dw.Image = pod.Spec.Containers[0].Image
+	}
+
+	return dw, pod, nil
+}
+
+// SetDelayedPhase sets the phase and message of delayed worker in the ConfigMap. The claim time is recorded when
+// it's Firing.
+func SetDelayedPhase(cm *corev1.ConfigMap, phase, message string) {
+	cm.Data[delayedPhaseKey], cm.Data[delayedMessageKey] = phase, message
+	if phase == apitypes.DelayedPhaseFiring {
+		cm.Data[delayedClaimedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	}
+}
+
+// IsDelayedWorkerDue returns true if the delayed worker should be claimed, which is either scheduled and reaches
+// runAt or claimed by a replica which doesn't finish it in time.
+func IsDelayedWorkerDue(cm *corev1.ConfigMap, now time.Time) bool {
+	switch cm.Data[delayedPhaseKey] {
+	case apitypes.DelayedPhaseScheduled:
+		runAt, err := time.Parse(time.RFC3339, cm.Data[delayedRunAtKey])
+		return err == nil && !now.Before(runAt)
+	case apitypes.DelayedPhaseFiring:
+		claimedAt, err := time.Parse(time.RFC3339, cm.Data[delayedClaimedAtKey])
+		return err != nil || now.Sub(claimedAt) > claimTimeout
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+package adapter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	reasonUnschedulable              string = "Unschedulable"
+	reasonImagePullBackOff           string = "ImagePullBackOff"
+	reasonErrImagePull               string = "ErrImagePull"
+	reasonInvalidImageName           string = "InvalidImageName"
+	reasonCreateContainerConfigError string = "CreateContainerConfigError"
+	reasonCreateContainerError       string = "CreateContainerError"
+	reasonCrashLoopBackOff           string = "CrashLoopBackOff"
+	reasonOOMKilled                  string = "OOMKilled"
+	reasonEvicted                    string = "Evicted"
+	reasonDeadlineExceeded           string = "DeadlineExceeded"
+	reasonFailedMount                string = "FailedMount"
+	reasonFailedScheduling           string = "FailedScheduling"
+	reasonFailedCreatePodSandBox     string = "FailedCreatePodSandBox"
+)
+
+// TranslateEvents translates Kubernetes events into WorkerEvent sorted by the last time.
+func TranslateEvents(events []corev1.Event) []v1types.WorkerEvent {
+	workerEvents := make([]v1types.WorkerEvent, 0, len(events))
+	for _, event := range events {
+		workerEvent := v1types.WorkerEvent{
+			Type:      event.Type,
+			Reason:    event.Reason,
+			Message:   event.Message,
+			Count:     event.Count,
+			Source:    event.Source.Component,
+			FirstTime: event.FirstTimestamp.Time,
+			LastTime:  event.LastTimestamp.Time,
+		}
+		// Events created by events.k8s.io API only have event time.
+		if workerEvent.FirstTime.IsZero() {
+			workerEvent.FirstTime = event.EventTime.Time
+		}
+		if workerEvent.LastTime.IsZero() {
+			workerEvent.LastTime = workerEvent.FirstTime
+		}
+		if workerEvent.Source == "" {
+			workerEvent.Source = event.ReportingController
+		}
+		workerEvents = append(workerEvents, workerEvent)
+	}
+	sort.SliceStable(workerEvents, func(i, j int) bool {
+		return workerEvents[i].LastTime.Before(workerEvents[j].LastTime)
+	})
+
+	return workerEvents
+}
+
+// DiagnosePod combines the Pod conditions, container states and events to explain why the worker is in its
+// current state, especially why it's pending, and suggests how to fix it.
+func DiagnosePod(pod *corev1.Pod, events []corev1.Event) *v1types.WorkerDiagnosis {
+	diagnosis := &v1types.WorkerDiagnosis{
+		WorkerStatus: *TranslatePodStatus(pod),
+		Problems:     []v1types.DiagnosisItem{},
+		Events:       TranslateEvents(events),
+	}
+	found := map[string]bool{}
+	add := func(reason, message, suggestion string) {
+		if found[reason] {
+			return
+		}
+		found[reason] = true
+		diagnosis.Problems = append(diagnosis.Problems, v1types.DiagnosisItem{
+			Reason:     reason,
+			Message:    message,
+			Suggestion: suggestion,
+		})
+	}
+
+	if pod.Status.Reason == reasonEvicted {
+		add(reasonEvicted, pod.Status.Message, "The node ran out of resources. Set resource requests close to "+
+			"the real usage and submit the worker again.")
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			add(reasonUnschedulable, condition.Message, suggestForUnschedulable(condition.Message))
+		}
+	}
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, containerStatus := range statuses {
+		if waiting := containerStatus.State.Waiting; waiting != nil {
+			if suggestion := suggestForWaiting(waiting.Reason, containerStatus.Image); suggestion != "" {
+				add(waiting.Reason, waiting.Message, suggestion)
+			}
+		}
+		terminated := containerStatus.State.Terminated
+		if terminated == nil {
+			terminated = containerStatus.LastTerminationState.Terminated
+		}
+		if terminated != nil && terminated.Reason == reasonOOMKilled {
+			add(reasonOOMKilled, fmt.Sprintf("Container %s exceeded its memory limit", containerStatus.Name),
+				"Increase the memory limit of the worker.")
+		}
+	}
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		switch event.Reason {
+		case reasonFailedScheduling:
+			add(reasonUnschedulable, event.Message, suggestForUnschedulable(event.Message))
+		case reasonFailedMount:
+			add(reasonFailedMount, event.Message, "Check the mount server, share path and the permission of "+
+				"the volume.")
+		case reasonFailedCreatePodSandBox:
+			add(reasonFailedCreatePodSandBox, event.Message, "The node fails to set up the Pod network or "+
+				"runtime. Delete the worker and submit it again, and contact the cluster admin if it persists.")
+		}
+	}
+
+	diagnosis.Summary = summarize(diagnosis)
+
+	return diagnosis
+}
+
+// suggestForUnschedulable suggests the fix according to the message of scheduler.
+func suggestForUnschedulable(message string) string {
+	msg := strings.ToLower(message)
+	switch {
+	case strings.Contains(msg, "insufficient cpu") || strings.Contains(msg, "insufficient memory"):
+		return "No node has enough free CPU or memory for the resource requests. Lower the requests or wait " +
+			"for other workers to finish."
+	case strings.Contains(msg, "taint"):
+		return "The nodes have taints which the worker doesn't tolerate. Contact the cluster admin."
+	case strings.Contains(msg, "node selector") || strings.Contains(msg, "affinity"):
+		return "No node matches the node selector or affinity of the worker."
+	case strings.Contains(msg, "persistentvolumeclaim"):
+		return "The persistent volume claim is not bound. Check whether the claim exists and is bound."
+	default:
+		return "The scheduler can't find a node for the worker. Check the scheduler message for details."
+	}
+}
+
+// suggestForWaiting suggests the fix according to the waiting reason of container. It returns empty string if
+// the reason is a normal step of starting container.
+func suggestForWaiting(reason, image string) string {
+	switch reason {
+	case reasonImagePullBackOff, reasonErrImagePull:
+		return fmt.Sprintf("Fail to pull image %s. Check the image name and version, and make sure the registry "+
+			"is reachable and doesn't require extra credentials.", image)
+	case reasonInvalidImageName:
+		return fmt.Sprintf("Image name %s is invalid. Fix the image and version of the worker.", image)
+	case reasonCreateContainerConfigError:
+		return "The container refers to a secret, config map or key which doesn't exist. Create it or fix the " +
+			"reference."
+	case reasonCreateContainerError:
+		return "The container runtime fails to create the container. Check the command and mounts of the worker."
+	case reasonCrashLoopBackOff:
+		return "The container keeps crashing. Check the logs with previous=true for the reason."
+	default:
+		return ""
+	}
+}
+
+// summarize writes one human-readable sentence for the diagnosis.
+func summarize(diagnosis *v1types.WorkerDiagnosis) string {
+	workerStatus := diagnosis.WorkerStatus
+	if len(diagnosis.Problems) == 0 {
+		return fmt.Sprintf("Worker is %s and its state is %s. No problem is found.",
+			workerStatus.Status, workerStatus.State)
+	}
+	reasons := make([]string, 0, len(diagnosis.Problems))
+	for _, problem := range diagnosis.Problems {
+		reasons = append(reasons, problem.Reason)
+	}
+
+	return fmt.Sprintf("Worker is %s and its state is %s because of %s. %s", workerStatus.Status,
+		workerStatus.State, strings.Join(reasons, ", "), diagnosis.Problems[0].Suggestion)
+}
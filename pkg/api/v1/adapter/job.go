@@ -0,0 +1,116 @@
+package adapter
+
+import (
+	"context"
+	"sort"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TranslateWorkerJobToJob translates the WorkerJob to Kubernetes Job whose Pod template is the same as the Pod
+// of TranslateWorkerPodToPod except the labels.
+func TranslateWorkerJobToJob(ctx context.Context, wj *v1types.WorkerJob) (*batchv1.Job, error) {
+	pod, err := TranslateWorkerPodToPod(ctx, &wj.WorkerPod)
+	if err != nil {
+		return nil, err
+	}
+	// The Pods of Job aren't workers.
+	delete(pod.Labels, apitypes.LabelManagedWorker)
+	podLabels := map[string]string{apitypes.LabelJobPod: apitypes.ManagedWorkerValue}
+	for k, v := range pod.Labels {
+		podLabels[k] = v
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: pod.GenerateName,
+			Namespace:    pod.Namespace,
+			Labels:       pod.Labels,
+			Annotations:  pod.Annotations,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          wj.BackoffLimit,
+			ActiveDeadlineSeconds: wj.ActiveDeadlineSeconds,
+			Completions:           wj.Completions,
+			Parallelism:           wj.Parallelism,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+
+	return job, nil
+}
+
+// TranslateJobStatus aggregates the status of Job and its Pods into JobStatus.
+func TranslateJobStatus(job *batchv1.Job, pods []corev1.Pod) *v1types.JobStatus {
+	jobStatus := &v1types.JobStatus{
+		Id:           job.GetName(),
+		Status:       apitypes.JobStatusPending,
+		Active:       job.Status.Active,
+		Succeeded:    job.Status.Succeeded,
+		Failed:       job.Status.Failed,
+		CreationTime: job.GetCreationTimestamp().Time,
+		Conditions:   []v1types.JobCondition{},
+		Workers:      []v1types.WorkerSummary{},
+	}
+	if job.Status.StartTime != nil {
+		startTime := job.Status.StartTime.Time
+		jobStatus.StartTime = &startTime
+	}
+	if job.Status.CompletionTime != nil {
+		completionTime := job.Status.CompletionTime.Time
+		jobStatus.CompletionTime = &completionTime
+	}
+	if job.Status.Active > 0 {
+		jobStatus.Status = apitypes.JobStatusRunning
+	}
+	for _, condition := range job.Status.Conditions {
+		jobStatus.Conditions = append(jobStatus.Conditions, v1types.JobCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time,
+		})
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			jobStatus.Status = apitypes.JobStatusComplete
+		case batchv1.JobFailed:
+			jobStatus.Status = apitypes.JobStatusFailed
+		}
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].GetCreationTimestamp().Time.Before(pods[j].GetCreationTimestamp().Time)
+	})
+	for i := range pods {
+		jobStatus.Workers = append(jobStatus.Workers, TranslatePodSummary(&pods[i]))
+	}
+
+	return jobStatus
+}
+
+// TranslatePodSummary translates the Pod into WorkerSummary.
+func TranslatePodSummary(pod *corev1.Pod) v1types.WorkerSummary {
+	summary := v1types.WorkerSummary{
+		Id:           pod.GetName(),
+		Namespace:    pod.GetNamespace(),
+		CreationTime: pod.GetCreationTimestamp().Time,
+		WorkerStatus: *TranslatePodStatus(pod),
+	}
+	if len(pod.Spec.Containers) > 0 {
+		summary.Image = pod.Spec.Containers[0].Image
+	}
+
+	return summary
+}
@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"reflect"
+	"testing"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestTranslateVolumeSource(t *testing.T) {
+	sizeLimit := resource.MustParse("1Gi")
+	tests := []struct {
+		name    string
+		mount   v1types.Mount
+		source  corev1.VolumeSource
+		wantErr bool
+	}{
+		{
+			name:   "nfs by default",
+			mount:  v1types.Mount{Server: "nfs.local", Share: "/data", MountPath: "/data"},
+			source: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "nfs.local", Path: "/data"}},
+		},
+		{
+			name:   "secret",
+			mount:  v1types.Mount{Type: apitypes.MountTypeKubeSecret, Source: "token", MountPath: "/token"},
+			source: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "token"}},
+		},
+		{
+			name:  "configmap",
+			mount: v1types.Mount{Type: apitypes.MountTypeConfigMap, Source: "settings", MountPath: "/etc/app"},
+			source: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+			}},
+		},
+		{
+			name:  "pvc",
+			mount: v1types.Mount{Type: apitypes.MountTypePVC, Source: "cache", MountPath: "/cache", ReadOnly: true},
+			source: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: "cache",
+				ReadOnly:  true,
+			}},
+		},
+		{
+			name:  "emptyDir in memory with size limit",
+			mount: v1types.Mount{Type: apitypes.MountTypeEmptyDir, Medium: "Memory", SizeLimit: "1Gi", MountPath: "/tmp"},
+			source: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMediumMemory,
+				SizeLimit: &sizeLimit,
+			}},
+		},
+		{
+			name:   "emptyDir in huge pages of size",
+			mount:  v1types.Mount{Type: apitypes.MountTypeEmptyDir, Medium: "HugePages-2Mi", MountPath: "/hugepages"},
+			source: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: "HugePages-2Mi"}},
+		},
+		{
+			name:    "invalid page size",
+			mount:   v1types.Mount{Type: apitypes.MountTypeEmptyDir, Medium: "HugePages-x", MountPath: "/hugepages"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown medium",
+			mount:   v1types.Mount{Type: apitypes.MountTypeEmptyDir, Medium: "Disk", MountPath: "/tmp"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid size limit",
+			mount:   v1types.Mount{Type: apitypes.MountTypeEmptyDir, SizeLimit: "large", MountPath: "/tmp"},
+			wantErr: true,
+		},
+		{
+			name:    "medium of other type",
+			mount:   v1types.Mount{Type: apitypes.MountTypeKubeSecret, Source: "token", Medium: "Memory", MountPath: "/t"},
+			wantErr: true,
+		},
+		{
+			name:    "no source",
+			mount:   v1types.Mount{Type: apitypes.MountTypeConfigMap, MountPath: "/etc/app"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			mount:   v1types.Mount{Type: "hostPath", Source: "/var", MountPath: "/var"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wp := &v1types.WorkerPod{Mounts: []v1types.Mount{test.mount}}
+			_, vols, err := wp.TranslateMounts()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("TranslateMounts() = %v, want error", vols)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TranslateMounts() fails: %v", err)
+			}
+			if !reflect.DeepEqual(vols[0].VolumeSource, test.source) {
+				t.Errorf("TranslateMounts() = %+v, want %+v", vols[0].VolumeSource, test.source)
+			}
+		})
+	}
+}
+
+func TestValidateMountNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			names: []string{"data", "cache-1"},
+		},
+		{
+			name:  "generated",
+			names: []string{"", ""},
+		},
+		{
+			name:    "not a DNS-1123 label",
+			names:   []string{"Data_1"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicated",
+			names:   []string{"data", "cache", "data"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mounts := []v1types.Mount{}
+			for _, name := range test.names {
+				mounts = append(mounts, v1types.Mount{Name: name, MountPath: "/" + name})
+			}
+			if err := validateMountNames(mounts); (err != nil) != test.wantErr {
+				t.Errorf("validateMountNames(%v) = %v, want error %v", test.names, err, test.wantErr)
+			}
+		})
+	}
+}
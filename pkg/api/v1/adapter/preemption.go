@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// preemptedStatusKey is the key of preempted worker ConfigMap data whose value is the worker status in JSON.
+	preemptedStatusKey string = "status"
+	// preemptedMessagePrefix is the prefix of the message of event recorded by scheduler for the preempted Pod,
+	// such as "Preempted by worker/worker-1 on node node-1".
+	preemptedMessagePrefix string = "Preempted by "
+)
+
+// PreemptorOf returns the ID of worker preempting the Pod of event recorded by scheduler. The namespace is prefixed
+// if the preemptor is in another namespace. It returns empty string if the preemptor is unknown.
+func PreemptorOf(event *corev1.Event) string {
+	namespace, name := "", ""
+	if event.Related != nil {
+		namespace, name = event.Related.Namespace, event.Related.Name
+	} else if strings.HasPrefix(event.Message, preemptedMessagePrefix) {
+		preemptor := strings.Fields(strings.TrimPrefix(event.Message, preemptedMessagePrefix))
+		if len(preemptor) == 0 {
+			return ""
+		}
+		parts := strings.SplitN(preemptor[0], "/", 2)
+		if len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+		} else {
+			name = parts[0]
+		}
+	}
+	if name == "" || namespace == "" || namespace == event.InvolvedObject.Namespace {
+		return name
+	}
+
+	return namespace + "/" + name
+}
+
+// TranslatePreemptedConfigMap translates the status of preempted worker to the ConfigMap which keeps it after the
+// Pod is deleted. The Pod is nil if it's deleted already.
+func TranslatePreemptedConfigMap(namespace, name string, pod *corev1.Pod, preemptor string) (*corev1.ConfigMap,
+	error) {
+	workerStatus := &v1types.WorkerStatus{
+		State:  apitypes.WorkerStatusTerminated,
+		Status: string(corev1.PodFailed),
+	}
+	if pod != nil {
+		workerStatus = TranslatePodStatus(pod)
+		workerStatus.State = apitypes.WorkerStatusTerminated
+		workerStatus.Status = string(corev1.PodFailed)
+	}
+	if workerStatus.FinishTime == nil {
+		now := time.Now()
+		workerStatus.FinishTime = &now
+	}
+	workerStatus.Reason = apitypes.WorkerStatusReasonPreempted
+	workerStatus.Message = "Worker is preempted by " + preemptor
+	workerStatus.PreemptedBy = preemptor
+	data, err := json.Marshal(workerStatus)
+	if err != nil {
+		return nil, err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				apitypes.LabelPreemptedWorker: name,
+			},
+		},
+		Data: map[string]string{
+			preemptedStatusKey: string(data),
+		},
+	}
+
+	return cm, nil
+}
+
+// TranslatePreemptedStatus parses the status of preempted worker from the ConfigMap.
+func TranslatePreemptedStatus(cm *corev1.ConfigMap) (*v1types.WorkerStatus, error) {
+	workerStatus := &v1types.WorkerStatus{}
+	if err := json.Unmarshal([]byte(cm.Data[preemptedStatusKey]), workerStatus); err != nil {
+		return nil, fmt.Errorf("invalid status of preempted worker %s: %v", cm.GetName(), err)
+	}
+
+	return workerStatus, nil
+}
@@ -0,0 +1,310 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// queuePodKey, queueJobKey, queuePriorityKey, queueOrderKey, queueEnqueuedAtKey, queuePhaseKey,
+	// queueMessageKey and queueClaimedAtKey are the keys of queued worker ConfigMap data. The queued Job has its Pod
+	// template as the Pod too. The order is the FIFO key among the workers with the same priority.
+	queuePodKey        string = "pod"
+	queueJobKey        string = "job"
+	queuePriorityKey   string = "priority"
+	queueOrderKey      string = "order"
+	queueEnqueuedAtKey string = "enqueuedAt"
+	queuePhaseKey      string = "phase"
+	queueMessageKey    string = "msg"
+	queueClaimedAtKey  string = "claimedAt"
+)
+
+// TranslatePodToQueuedConfigMap names the Pod if it has no name yet and translates it to the ConfigMap which holds
+// it in admission queue. The ConfigMap has the same name as the Pod.
+func TranslatePodToQueuedConfigMap(pod *corev1.Pod) (*corev1.ConfigMap, error) {
+	namePod(pod)
+	cm := &corev1.ConfigMap{}
+	cm.Name, cm.Namespace = pod.GetName(), pod.GetNamespace()
+
+	return cm, SetQueuedConfigMap(cm, pod)
+}
+
+// TranslateJobToQueuedConfigMap names the Job if it has no name yet and translates it to the ConfigMap which holds
+// it in admission queue. The queued Job is limited as one worker by the Pod of its template.
+func TranslateJobToQueuedConfigMap(job *batchv1.Job) (*corev1.ConfigMap, error) {
+	if job.Name == "" {
+		job.Name = job.GenerateName + uuid.New().String()[:5]
+		job.GenerateName = ""
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        job.GetName(),
+			Namespace:   job.GetNamespace(),
+			Labels:      job.Spec.Template.Labels,
+			Annotations: job.Spec.Template.Annotations,
+		},
+		Spec: job.Spec.Template.Spec,
+	}
+	cm, err := TranslatePodToQueuedConfigMap(pod)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	cm.Data[queueJobKey] = string(data)
+
+	return cm, nil
+}
+
+// QueuedJob parses the Job held in the queued worker ConfigMap. It returns nil if the queued worker is a Pod.
+func QueuedJob(cm *corev1.ConfigMap) (*batchv1.Job, error) {
+	data, ok := cm.Data[queueJobKey]
+	if !ok {
+		return nil, nil
+	}
+	job := &batchv1.Job{}
+	if err := json.Unmarshal([]byte(data), job); err != nil {
+		return nil, fmt.Errorf("invalid Job of queued worker %s: %v", cm.GetName(), err)
+	}
+
+	return job, nil
+}
+
+// SetQueuedConfigMap turns the ConfigMap of the Pod into the one of queued worker. The delayed worker is queued in
+// this way when it's due.
+func SetQueuedConfigMap(cm *corev1.ConfigMap, pod *corev1.Pod) error {
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return err
+	}
+	priority, _ := strconv.ParseInt(pod.Annotations[apitypes.AnnotationPriority], 10, 32)
+	now := time.Now()
+	cm.Labels = map[string]string{
+		apitypes.LabelQueuedWorker: pod.GetName(),
+	}
+	// The queued worker of array is labeled with it, so that it's listed with the array.
+	if arrayID, ok := pod.Labels[apitypes.LabelArray]; ok {
+		cm.Labels[apitypes.LabelArray] = arrayID
+	}
+	cm.Data = map[string]string{
+		queuePodKey:        string(data),
+		queuePriorityKey:   strconv.FormatInt(priority, 10),
+		queueOrderKey:      strconv.FormatInt(now.UnixNano(), 10),
+		queueEnqueuedAtKey: now.UTC().Format(time.RFC3339),
+		queuePhaseKey:      apitypes.QueuePhaseQueued,
+	}
+
+	return nil
+}
+
+// TranslateConfigMapToQueuedWorker parses the queued worker and its Pod from the ConfigMap. The position isn't
+// set.
+func TranslateConfigMapToQueuedWorker(cm *corev1.ConfigMap) (*v1types.QueuedWorker, *corev1.Pod, error) {
+	pod := &corev1.Pod{}
+	if err := json.Unmarshal([]byte(cm.Data[queuePodKey]), pod); err != nil {
+		return nil, nil, fmt.Errorf("invalid Pod of queued worker %s: %v", cm.GetName(), err)
+	}
+	enqueueTime, err := time.Parse(time.RFC3339, cm.Data[queueEnqueuedAtKey])
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid enqueue time of queued worker %s: %v", cm.GetName(), err)
+	}
+	qw := &v1types.QueuedWorker{
+		Id:          cm.GetName(),
+		Namespace:   cm.GetNamespace(),
+		User:        PodUser(pod),
+		Priority:    QueuePriority(cm),
+		State:       cm.Data[queuePhaseKey],
+		Message:     cm.Data[queueMessageKey],
+		EnqueueTime: enqueueTime,
+	}
+	if len(pod.Spec.Containers) > 0 {
+		qw.Image = pod.Spec.Containers[0].Image
+	}
+
+	return qw, pod, nil
+}
+
+// PodUser returns the user of worker, which is the user env of worker container.
+func PodUser(pod *corev1.Pod) string {
+	if len(pod.Spec.Containers) == 0 {
+		return ""
+	}
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == v1types.EnvUser {
+			return env.Value
+		}
+	}
+
+	return ""
+}
+
+// QueuePriority returns the priority of queued worker.
+func QueuePriority(cm *corev1.ConfigMap) int32 {
+	priority, _ := strconv.ParseInt(cm.Data[queuePriorityKey], 10, 32)
+
+	return int32(priority)
+}
+
+func queueOrder(cm *corev1.ConfigMap) int64 {
+	order, _ := strconv.ParseInt(cm.Data[queueOrderKey], 10, 64)
+
+	return order
+}
+
+// IsQueuedWorkerFailed returns true if the queued worker can't be created and won't be dispatched again.
+func IsQueuedWorkerFailed(cm *corev1.ConfigMap) bool {
+	return cm.Data[queuePhaseKey] == apitypes.QueuePhaseFailed
+}
+
+// SortQueue sorts the queued worker ConfigMaps in dispatch order, which is by priority from high to low and then
+// by submission order.
+func SortQueue(cms []corev1.ConfigMap) {
+	sort.SliceStable(cms, func(i, j int) bool {
+		pi, pj := QueuePriority(&cms[i]), QueuePriority(&cms[j])
+		if pi != pj {
+			return pi > pj
+		}
+		oi, oj := queueOrder(&cms[i]), queueOrder(&cms[j])
+		if oi != oj {
+			return oi < oj
+		}
+
+		return cms[i].GetName() < cms[j].GetName()
+	})
+}
+
+// SetQueuedPhase sets the phase and message of queued worker. The claim time is recorded when it's Dispatching.
+// It returns false if nothing is changed.
+func SetQueuedPhase(cm *corev1.ConfigMap, phase, message string) bool {
+	if cm.Data[queuePhaseKey] == phase && cm.Data[queueMessageKey] == message {
+		return false
+	}
+	cm.Data[queuePhaseKey], cm.Data[queueMessageKey] = phase, message
+	if phase == apitypes.QueuePhaseDispatching {
+		cm.Data[queueClaimedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	return true
+}
+
+// IsQueuedWorkerClaimed returns true if the queued worker is claimed by a replica which may still be creating it.
+// The claim times out in case the replica exits before creating the Pod.
+func IsQueuedWorkerClaimed(cm *corev1.ConfigMap, now time.Time) bool {
+	if cm.Data[queuePhaseKey] != apitypes.QueuePhaseDispatching {
+		return false
+	}
+	claimedAt, err := time.Parse(time.RFC3339, cm.Data[queueClaimedAtKey])
+
+	return err == nil && now.Sub(claimedAt) <= claimTimeout
+}
+
+// ReorderQueuedWorker sets the priority of queued worker and moves it to the front or back of the queued workers
+// with the same priority.
+func ReorderQueuedWorker(cm *corev1.ConfigMap, update *v1types.QueueUpdate, queue []corev1.ConfigMap) error {
+	if update.Priority != nil {
+		cm.Data[queuePriorityKey] = strconv.Itoa(int(*update.Priority))
+	}
+	if update.Position == "" {
+		return nil
+	}
+	if update.Position != apitypes.QueuePositionFront && update.Position != apitypes.QueuePositionBack {
+		return fmt.Errorf("position must be %s or %s", apitypes.QueuePositionFront, apitypes.QueuePositionBack)
+	}
+	priority, order := QueuePriority(cm), queueOrder(cm)
+	for i := range queue {
+		if queue[i].GetName() == cm.GetName() && queue[i].GetNamespace() == cm.GetNamespace() {
+			continue
+		}
+		if QueuePriority(&queue[i]) != priority {
+			continue
+		}
+		if other := queueOrder(&queue[i]); update.Position == apitypes.QueuePositionFront && other <= order {
+			order = other - 1
+		} else if update.Position == apitypes.QueuePositionBack && other >= order {
+			order = other + 1
+		}
+	}
+	cm.Data[queueOrderKey] = strconv.FormatInt(order, 10)
+
+	return nil
+}
+
+// TranslateQueue translates the sorted queued worker ConfigMaps to queued workers with their positions. The failed
+// ones have no position since they're not dispatched.
+func TranslateQueue(cms []corev1.ConfigMap) []v1types.QueuedWorker {
+	queue := make([]v1types.QueuedWorker, 0, len(cms))
+	position := 0
+	for i := range cms {
+		qw, _, err := TranslateConfigMapToQueuedWorker(&cms[i])
+		if err != nil {
+			continue
+		}
+		if !IsQueuedWorkerFailed(&cms[i]) {
+			position++
+			qw.Position = position
+		}
+		queue = append(queue, *qw)
+	}
+
+	return queue
+}
+
+// TranslateQueuedStatus returns the status of worker waiting in admission queue, whose state and reason are
+// Queued. It returns nil if the ConfigMap is invalid.
+func TranslateQueuedStatus(cm *corev1.ConfigMap) *v1types.WorkerStatus {
+	qw, _, err := TranslateConfigMapToQueuedWorker(cm)
+	if err != nil {
+		return nil
+	}
+	workerStatus := &v1types.WorkerStatus{
+		State:   apitypes.WorkerStatusQueued,
+		Status:  qw.State,
+		Reason:  apitypes.WorkerStatusQueued,
+		Message: qw.Message,
+	}
+	if qw.State == apitypes.QueuePhaseFailed {
+		workerStatus.Reason = apitypes.QueuePhaseFailed
+	}
+
+	return workerStatus
+}
+
+// TranslateQueuedJobStatus returns the status of Job waiting in admission queue, which is Queued, or Failed with
+// the reason if it can't be created. It returns nil if the ConfigMap is invalid.
+func TranslateQueuedJobStatus(cm *corev1.ConfigMap) *v1types.JobStatus {
+	qw, _, err := TranslateConfigMapToQueuedWorker(cm)
+	if err != nil {
+		return nil
+	}
+	jobStatus := &v1types.JobStatus{
+		Id:           qw.Id,
+		Status:       apitypes.JobStatusQueued,
+		CreationTime: qw.EnqueueTime,
+		Conditions:   []v1types.JobCondition{},
+		Workers:      []v1types.WorkerSummary{},
+	}
+	if qw.State == apitypes.QueuePhaseFailed {
+		jobStatus.Status = apitypes.JobStatusFailed
+		jobStatus.Conditions = append(jobStatus.Conditions, v1types.JobCondition{
+			Type:    apitypes.JobStatusFailed,
+			Status:  string(corev1.ConditionTrue),
+			Reason:  apitypes.QueuePhaseFailed,
+			Message: qw.Message,
+		})
+	}
+
+	return jobStatus
+}
@@ -0,0 +1,160 @@
+package adapter
+
+import (
+	"strconv"
+	"testing"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func queuedConfigMap(name string, priority int32, order int64) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Data: map[string]string{
+			queuePriorityKey: strconv.Itoa(int(priority)),
+			queueOrderKey:    strconv.FormatInt(order, 10),
+		},
+	}
+}
+
+func queueNames(cms []corev1.ConfigMap) []string {
+	names := []string{}
+	for i := range cms {
+		names = append(names, cms[i].GetName())
+	}
+
+	return names
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestSortQueue(t *testing.T) {
+	tests := []struct {
+		name  string
+		queue []corev1.ConfigMap
+		want  []string
+	}{
+		{
+			name: "by priority",
+			queue: []corev1.ConfigMap{
+				queuedConfigMap("a", 0, 1),
+				queuedConfigMap("b", 10, 2),
+				queuedConfigMap("c", -5, 3),
+			},
+			want: []string{"b", "a", "c"},
+		},
+		{
+			name: "by order with the same priority",
+			queue: []corev1.ConfigMap{
+				queuedConfigMap("a", 1, 3),
+				queuedConfigMap("b", 1, 1),
+				queuedConfigMap("c", 1, 2),
+			},
+			want: []string{"b", "c", "a"},
+		},
+		{
+			name: "by name with the same order",
+			queue: []corev1.ConfigMap{
+				queuedConfigMap("b", 1, 1),
+				queuedConfigMap("a", 1, 1),
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SortQueue(test.queue)
+			if names := queueNames(test.queue); !equalNames(names, test.want) {
+				t.Errorf("SortQueue() = %v, want %v", names, test.want)
+			}
+		})
+	}
+}
+
+func TestReorderQueuedWorker(t *testing.T) {
+	priority := func(p int32) *int32 {
+		return &p
+	}
+	tests := []struct {
+		name    string
+		target  string
+		update  v1types.QueueUpdate
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "front",
+			target: "c",
+			update: v1types.QueueUpdate{Position: apitypes.QueuePositionFront},
+			want:   []string{"high", "c", "a", "b"},
+		},
+		{
+			name:   "back",
+			target: "a",
+			update: v1types.QueueUpdate{Position: apitypes.QueuePositionBack},
+			want:   []string{"high", "b", "c", "a"},
+		},
+		{
+			name:   "priority",
+			target: "b",
+			update: v1types.QueueUpdate{Priority: priority(20)},
+			want:   []string{"b", "high", "a", "c"},
+		},
+		{
+			name:   "front of the new priority",
+			target: "c",
+			update: v1types.QueueUpdate{Priority: priority(10), Position: apitypes.QueuePositionFront},
+			want:   []string{"c", "high", "a", "b"},
+		},
+		{
+			name:    "invalid position",
+			target:  "a",
+			update:  v1types.QueueUpdate{Position: "middle"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := []corev1.ConfigMap{
+				queuedConfigMap("high", 10, 5),
+				queuedConfigMap("a", 0, 1),
+				queuedConfigMap("b", 0, 2),
+				queuedConfigMap("c", 0, 3),
+			}
+			var cm *corev1.ConfigMap
+			for i := range queue {
+				if queue[i].GetName() == test.target {
+					cm = &queue[i]
+				}
+			}
+			err := ReorderQueuedWorker(cm, &test.update, queue)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("ReorderQueuedWorker() succeeds, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReorderQueuedWorker() fails: %v", err)
+			}
+			SortQueue(queue)
+			if names := queueNames(queue); !equalNames(names, test.want) {
+				t.Errorf("queue = %v, want %v", names, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// kservicePrefix is the prefix of labels and annotations managed by kservice.
+const kservicePrefix string = "kservice/"
+
+// InitWorkerRerun parses the overrides of worker to rerun. The empty body means no override.
+func InitWorkerRerun(jsonBody io.ReadCloser) (*v1types.WorkerRerun, error) {
+	rerun := &v1types.WorkerRerun{}
+	if jsonBody == nil {
+		return rerun, nil
+	}
+	err := json.NewDecoder(jsonBody).Decode(rerun)
+	if err == io.EOF {
+		err = nil
+	}
+
+	return rerun, err
+}
+
+// TranslatePodToRerunWorkerPod rebuilds the WorkerPod from the Pod of worker and applies the overrides. The labels
+// and annotations managed by kservice aren't copied, so the rerun isn't part of array, workflow or retries of the
+// worker. The rerun is annotated with the worker it reruns and the original worker.
+func TranslatePodToRerunWorkerPod(ctx context.Context, pod *corev1.Pod, rerun *v1types.WorkerRerun) *v1types.WorkerPod {
+	wp := TranslatePodToWorkerPod(ctx, pod)
+	defaultWP := newDefaultWorkerPod(ctx)
+	wp.Name = defaultWP.Name
+	wp.Prefix = defaultWP.Prefix + "-" + requestIDPrefix(ctx) + "-"
+	wp.Labels = userMetadata(pod.GetLabels())
+	wp.Annotations = userMetadata(pod.GetAnnotations())
+	origin := pod.Annotations[apitypes.AnnotationRerunOrigin]
+	if origin == "" {
+		origin = pod.GetName()
+	}
+	wp.Annotations[apitypes.AnnotationRerunOf] = pod.GetName()
+	wp.Annotations[apitypes.AnnotationRerunOrigin] = origin
+	// The user env is added again from UserInfo.
+	if wp.UserInfo.UserID != nil {
+		delete(wp.Env, v1types.EnvUser)
+	}
+
+	if rerun.ImageVersion != "" {
+		wp.ImageVersion = rerun.ImageVersion
+	}
+	for k, v := range rerun.Env {
+		wp.Env[k] = v
+	}
+	if rerun.ResourceLimits != nil {
+		wp.ResourceLimits = rerun.ResourceLimits
+	}
+	if rerun.ResourceRequests != nil {
+		wp.ResourceRequests = rerun.ResourceRequests
+	}
+
+	return wp
+}
+
+// userMetadata returns the copy of labels or annotations without the ones managed by kservice.
+func userMetadata(metadata map[string]string) map[string]string {
+	copied := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if !strings.HasPrefix(k, kservicePrefix) {
+			copied[k] = v
+		}
+	}
+
+	return copied
+}
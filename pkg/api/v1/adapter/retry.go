@@ -0,0 +1,252 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultRetryBackoffSeconds and defaultRetryMaxBackoffSeconds are the default delays before the next attempt.
+	defaultRetryBackoffSeconds    int32 = 10
+	defaultRetryMaxBackoffSeconds int32 = 300
+	// maxRetryAttempts is the maximum number of attempts of worker.
+	maxRetryAttempts int32 = 100
+)
+
+// completeRetryPolicy checks the retry policy and sets the default backoff.
+func completeRetryPolicy(policy *v1types.RetryPolicy) error {
+	if policy.MaxAttempts < 1 || policy.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("retryPolicy.maxAttempts must be between 1 and %d", maxRetryAttempts)
+	}
+	if policy.BackoffSeconds < 0 || policy.MaxBackoffSeconds < 0 || policy.ImagePullTimeoutSeconds < 0 {
+		return errors.New("retryPolicy can't have negative seconds")
+	}
+	if policy.BackoffSeconds == 0 {
+		policy.BackoffSeconds = defaultRetryBackoffSeconds
+	}
+	if policy.MaxBackoffSeconds == 0 {
+		policy.MaxBackoffSeconds = defaultRetryMaxBackoffSeconds
+	}
+	if policy.MaxBackoffSeconds < policy.BackoffSeconds {
+		policy.MaxBackoffSeconds = policy.BackoffSeconds
+	}
+
+	return nil
+}
+
+// ApplyRetryPolicy names the Pod as the first attempt of worker with the retry policy. The Pod name is also the
+// worker ID shared by all attempts.
+func ApplyRetryPolicy(pod *corev1.Pod, policy *v1types.RetryPolicy) error {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	namePod(pod)
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Labels[apitypes.LabelRetryOf] = pod.Name
+	pod.Annotations[apitypes.AnnotationRetryPolicy] = string(data)
+	pod.Annotations[apitypes.AnnotationAttempt] = "1"
+
+	return nil
+}
+
+// PodRetryPolicy returns the retry policy of attempt Pod.
+func PodRetryPolicy(pod *corev1.Pod) (*v1types.RetryPolicy, error) {
+	policy := &v1types.RetryPolicy{}
+	if err := json.Unmarshal([]byte(pod.Annotations[apitypes.AnnotationRetryPolicy]), policy); err != nil {
+		return nil, fmt.Errorf("invalid retry policy of %s: %v", pod.GetName(), err)
+	}
+
+	return policy, nil
+}
+
+// PodAttempt returns the attempt number of attempt Pod.
+func PodAttempt(pod *corev1.Pod) int32 {
+	attempt, err := strconv.Atoi(pod.Annotations[apitypes.AnnotationAttempt])
+	if err != nil {
+		return 0
+	}
+
+	return int32(attempt)
+}
+
+// SortAttempts sorts the attempt Pods by attempt number.
+func SortAttempts(pods []corev1.Pod) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		return PodAttempt(&pods[i]) < PodAttempt(&pods[j])
+	})
+}
+
+// AttemptReason returns the reason of failed attempt Pod. The reason set by kservice is preferred, then eviction
+// and OOM kill, then the timeout and the reason of the terminated worker container.
+func AttemptReason(pod *corev1.Pod) string {
+	if reason := pod.Annotations[apitypes.AnnotationFailReason]; reason != "" {
+		return reason
+	}
+	workerStatus := TranslatePodStatus(pod)
+	switch {
+	case workerStatus.Evicted:
+		return reasonEvicted
+	case workerStatus.OOMKilled:
+		return reasonOOMKilled
+	case workerStatus.Reason == apitypes.WorkerStatusReasonTimedOut:
+		return workerStatus.Reason
+	case workerStatus.State == apitypes.WorkerStatusTerminated && workerStatus.Reason != "":
+		return workerStatus.Reason
+	}
+
+	return pod.Status.Reason
+}
+
+// IsImagePullStuck returns true if the worker container of attempt Pod can't pull image within the timeout of
+// retry policy.
+func IsImagePullStuck(pod *corev1.Pod, policy *v1types.RetryPolicy, now time.Time) bool {
+	if policy.ImagePullTimeoutSeconds == 0 || pod.Status.Phase != corev1.PodPending || pod.Status.StartTime == nil {
+		return false
+	}
+	if _, failing := pod.Annotations[apitypes.AnnotationFailReason]; failing {
+		return false
+	}
+	workerStatus := TranslatePodStatus(pod)
+	if workerStatus.State != apitypes.WorkerStatusWaiting ||
+		(workerStatus.Reason != reasonImagePullBackOff && workerStatus.Reason != reasonErrImagePull) {
+		return false
+	}
+
+	return now.Sub(pod.Status.StartTime.Time) >= time.Duration(policy.ImagePullTimeoutSeconds)*time.Second
+}
+
+// IsAttemptRetryable returns true if the failed attempt Pod matches the exit codes or reasons of retry policy.
+func IsAttemptRetryable(pod *corev1.Pod, policy *v1types.RetryPolicy) bool {
+	if pod.Status.Phase != corev1.PodFailed {
+		return false
+	}
+	if len(policy.ExitCodes) == 0 && len(policy.Reasons) == 0 {
+		return true
+	}
+	if containsString(policy.Reasons, AttemptReason(pod)) {
+		return true
+	}
+	workerStatus := TranslatePodStatus(pod)
+	if workerStatus.ExitCode != nil {
+		for _, exitCode := range policy.ExitCodes {
+			if exitCode == *workerStatus.ExitCode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RetryDelay returns the backoff before the next attempt after the given attempt fails. It doubles every attempt
+// up to the maximum backoff.
+func RetryDelay(policy *v1types.RetryPolicy, attempt int32) time.Duration {
+	delay := time.Duration(policy.BackoffSeconds) * time.Second
+	maxDelay := time.Duration(policy.MaxBackoffSeconds) * time.Second
+	for i := int32(1); i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
+
+// AttemptFinishTime returns the time when the failed attempt Pod finishes. The Pod which fails before its worker
+// container starts, such as the evicted one, falls back to the latest transition of its conditions.
+func AttemptFinishTime(pod *corev1.Pod) time.Time {
+	if finishTime := TranslatePodStatus(pod).FinishTime; finishTime != nil {
+		return *finishTime
+	}
+	finishTime := pod.GetCreationTimestamp().Time
+	for _, condition := range pod.Status.Conditions {
+		if condition.LastTransitionTime.After(finishTime) {
+			finishTime = condition.LastTransitionTime.Time
+		}
+	}
+
+	return finishTime
+}
+
+// TranslateNextAttempt translates the failed attempt Pod to the Pod of next attempt which has the same spec and is
+// named by the worker ID and the attempt number.
+func TranslateNextAttempt(pod *corev1.Pod) *corev1.Pod {
+	id := pod.Labels[apitypes.LabelRetryOf]
+	attempt := PodAttempt(pod) + 1
+	next := &corev1.Pod{}
+	next.Name = fmt.Sprintf("%s-%d", id, attempt)
+	next.Namespace = pod.GetNamespace()
+	next.Labels = map[string]string{}
+	for k, v := range pod.GetLabels() {
+		next.Labels[k] = v
+	}
+	next.Annotations = map[string]string{}
+	for k, v := range pod.GetAnnotations() {
+		next.Annotations[k] = v
+	}
+	next.Annotations[apitypes.AnnotationAttempt] = strconv.Itoa(int(attempt))
+	delete(next.Annotations, apitypes.AnnotationRetried)
+	delete(next.Annotations, apitypes.AnnotationFailReason)
+	delete(next.Annotations, apitypes.AnnotationActiveDeadline)
+	next.Spec = *pod.Spec.DeepCopy()
+	next.Spec.NodeName = ""
+	// Restore the deadline of attempt failed by kservice on purpose.
+	if deadline, ok := pod.Annotations[apitypes.AnnotationActiveDeadline]; ok {
+		next.Spec.ActiveDeadlineSeconds = nil
+		if seconds, err := strconv.ParseInt(deadline, 10, 64); err == nil && seconds > 0 {
+			next.Spec.ActiveDeadlineSeconds = &seconds
+		}
+	}
+
+	return next
+}
+
+// TranslateRetryStatus parses the status of worker with retry policy from all its attempt Pods sorted by attempt.
+// The top-level status is of the latest attempt.
+func TranslateRetryStatus(pods []corev1.Pod) *v1types.WorkerStatus {
+	if len(pods) == 0 {
+		return nil
+	}
+	attempts := make([]v1types.WorkerAttempt, 0, len(pods))
+	for i := range pods {
+		attemptStatus := TranslatePodStatus(&pods[i])
+		attempt := v1types.WorkerAttempt{
+			Attempt:    PodAttempt(&pods[i]),
+			Id:         pods[i].GetName(),
+			Status:     attemptStatus.Status,
+			State:      attemptStatus.State,
+			Reason:     attemptStatus.Reason,
+			ExitCode:   attemptStatus.ExitCode,
+			StartTime:  attemptStatus.StartTime,
+			FinishTime: attemptStatus.FinishTime,
+		}
+		if pods[i].Status.Phase == corev1.PodFailed {
+			attempt.Reason = AttemptReason(&pods[i])
+		}
+		attempts = append(attempts, attempt)
+	}
+	latest := &pods[len(pods)-1]
+	workerStatus := TranslatePodStatus(latest)
+	if latest.Status.Phase == corev1.PodFailed {
+		workerStatus.Reason = AttemptReason(latest)
+	}
+	workerStatus.Attempt = PodAttempt(latest)
+	workerStatus.Attempts = attempts
+
+	return workerStatus
+}
@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"testing"
+	"time"
+
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  v1types.RetryPolicy
+		attempt int32
+		delay   time.Duration
+	}{
+		{
+			name:    "first attempt",
+			policy:  v1types.RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 300},
+			attempt: 1,
+			delay:   10 * time.Second,
+		},
+		{
+			name:    "doubled",
+			policy:  v1types.RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 300},
+			attempt: 3,
+			delay:   40 * time.Second,
+		},
+		{
+			name:    "capped",
+			policy:  v1types.RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 300},
+			attempt: 6,
+			delay:   300 * time.Second,
+		},
+		{
+			name:    "no overflow",
+			policy:  v1types.RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 300},
+			attempt: 1 << 30,
+			delay:   300 * time.Second,
+		},
+		{
+			name:    "backoff exceeds maximum",
+			policy:  v1types.RetryPolicy{BackoffSeconds: 600, MaxBackoffSeconds: 300},
+			attempt: 1,
+			delay:   300 * time.Second,
+		},
+		{
+			name:    "no backoff",
+			policy:  v1types.RetryPolicy{},
+			attempt: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if delay := RetryDelay(&test.policy, test.attempt); delay != test.delay {
+				t.Errorf("RetryDelay() = %v, want %v", delay, test.delay)
+			}
+		})
+	}
+}
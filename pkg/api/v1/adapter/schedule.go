@@ -0,0 +1,130 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// InitWorkerSchedule inits a default WorkerSchedule spec and validates the schedule parameters.
+func InitWorkerSchedule(ctx context.Context, jsonBody io.ReadCloser) (*v1types.WorkerSchedule, error) {
+	ws := &v1types.WorkerSchedule{WorkerJob: v1types.WorkerJob{WorkerPod: *newDefaultWorkerPod(ctx)}}
+	err := json.NewDecoder(jsonBody).Decode(ws)
+	if err = completeWorkerPod(ctx, &ws.WorkerPod, err); err != nil {
+		return ws, err
+	}
+	if err = rejectPodOnlyFields(&ws.WorkerPod); err != nil {
+		return ws, err
+	}
+	ws.Schedule = strings.TrimSpace(ws.Schedule)
+	if ws.Schedule == "" {
+		return ws, errors.New("schedule is a mandatory parameter")
+	}
+	if strings.HasPrefix(ws.Schedule, "CRON_TZ=") || strings.HasPrefix(ws.Schedule, "TZ=") {
+		return ws, errors.New("time zone in schedule is not supported")
+	}
+	switch batchv1beta1.ConcurrencyPolicy(ws.ConcurrencyPolicy) {
+	case "":
+		ws.ConcurrencyPolicy = string(batchv1beta1.AllowConcurrent)
+	case batchv1beta1.AllowConcurrent, batchv1beta1.ForbidConcurrent, batchv1beta1.ReplaceConcurrent:
+	default:
+		return ws, fmt.Errorf("concurrencyPolicy should be one of %s, %s and %s", batchv1beta1.AllowConcurrent,
+			batchv1beta1.ForbidConcurrent, batchv1beta1.ReplaceConcurrent)
+	}
+
+	return ws, nil
+}
+
+// TranslateWorkerScheduleToCronJob translates the WorkerSchedule to Kubernetes CronJob whose Job template is the
+// same as the Job of TranslateWorkerJobToJob. The CronJob is named in advance so that its Jobs are labeled with it.
+func TranslateWorkerScheduleToCronJob(ctx context.Context, ws *v1types.WorkerSchedule) (*batchv1beta1.CronJob, error) {
+	job, err := TranslateWorkerJobToJob(ctx, &ws.WorkerJob)
+	if err != nil {
+		return nil, err
+	}
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: job.ObjectMeta,
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:                   ws.Schedule,
+			ConcurrencyPolicy:          batchv1beta1.ConcurrencyPolicy(ws.ConcurrencyPolicy),
+			Suspend:                    ws.Suspend,
+			StartingDeadlineSeconds:    ws.StartingDeadlineSeconds,
+			SuccessfulJobsHistoryLimit: ws.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     ws.FailedJobsHistoryLimit,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      job.Labels,
+					Annotations: job.Annotations,
+				},
+				Spec: job.Spec,
+			},
+		},
+	}
+	if cronJob.Name == "" {
+		cronJob.Name = cronJob.GenerateName + uuid.New().String()[:5]
+		cronJob.GenerateName = ""
+	}
+	SetScheduleLabel(cronJob)
+
+	return cronJob, nil
+}
+
+// SetScheduleLabel labels the Job template of CronJob with its name, so the Jobs created by it can be selected.
+func SetScheduleLabel(cronJob *batchv1beta1.CronJob) {
+	labels := map[string]string{}
+	for k, v := range cronJob.Spec.JobTemplate.Labels {
+		labels[k] = v
+	}
+	labels[apitypes.LabelSchedule] = cronJob.GetName()
+	cronJob.Spec.JobTemplate.Labels = labels
+}
+
+// TranslateCronJobStatus translates the CronJob into ScheduleStatus.
+func TranslateCronJobStatus(cronJob *batchv1beta1.CronJob) *v1types.ScheduleStatus {
+	scheduleStatus := &v1types.ScheduleStatus{
+		Id:                         cronJob.GetName(),
+		Namespace:                  cronJob.GetNamespace(),
+		Schedule:                   cronJob.Spec.Schedule,
+		ConcurrencyPolicy:          string(cronJob.Spec.ConcurrencyPolicy),
+		Suspend:                    cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend,
+		StartingDeadlineSeconds:    cronJob.Spec.StartingDeadlineSeconds,
+		SuccessfulJobsHistoryLimit: cronJob.Spec.SuccessfulJobsHistoryLimit,
+		FailedJobsHistoryLimit:     cronJob.Spec.FailedJobsHistoryLimit,
+		CreationTime:               cronJob.GetCreationTimestamp().Time,
+		Active:                     []string{},
+	}
+	if containers := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers; len(containers) > 0 {
+		scheduleStatus.Image = containers[0].Image
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		lastScheduleTime := cronJob.Status.LastScheduleTime.Time
+		scheduleStatus.LastScheduleTime = &lastScheduleTime
+	}
+	for _, ref := range cronJob.Status.Active {
+		scheduleStatus.Active = append(scheduleStatus.Active, ref.Name)
+	}
+
+	return scheduleStatus
+}
+
+// IsScheduledJob returns true if the Job is created by the CronJob.
+func IsScheduledJob(cronJob *batchv1beta1.CronJob, job *batchv1.Job) bool {
+	for _, ref := range job.GetOwnerReferences() {
+		if ref.UID == cronJob.GetUID() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// InitWorkerService inits a default WorkerService spec and validates the replicas and ports.
+func InitWorkerService(ctx context.Context, jsonBody io.ReadCloser) (*v1types.WorkerService, error) {
+	ws := &v1types.WorkerService{WorkerPod: *newDefaultWorkerPod(ctx)}
+	err := json.NewDecoder(jsonBody).Decode(ws)
+	if err = completeWorkerPod(ctx, &ws.WorkerPod, err); err != nil {
+		return ws, err
+	}
+	if err = rejectPodOnlyFields(&ws.WorkerPod); err != nil {
+		return ws, err
+	}
+	if ws.MaxRuntime > 0 || ws.MaxPending > 0 {
+		return ws, errors.New("maxRuntime and maxPending are not supported by service")
+	}
+	if ws.Replicas != nil && *ws.Replicas < 0 {
+		return ws, errors.New("replicas should not be negative")
+	}
+	for _, port := range ws.Ports {
+		if port <= 0 || port > 65535 {
+			return ws, fmt.Errorf("invalid port %d", port)
+		}
+	}
+	if ws.ReadinessPath != "" && len(ws.Ports) == 0 {
+		return ws, errors.New("readinessPath requires ports")
+	}
+
+	return ws, nil
+}
+
+// ServiceID returns the service ID of the request.
+func ServiceID(ctx context.Context) string {
+	return "service-" + requestIDPrefix(ctx)
+}
+
+// TranslateWorkerServiceToDeployment translates the WorkerService to Kubernetes Deployment whose Pod template is
+// the same as the Pod of TranslateWorkerPodToPod except that it's always restarted, and to the Service of its ports.
+// The Service is nil if there is no port.
+func TranslateWorkerServiceToDeployment(ctx context.Context, id string, ws *v1types.WorkerService) (
+	*appsv1.Deployment, *corev1.Service, error) {
+	pod, err := TranslateWorkerPodToPod(ctx, &ws.WorkerPod)
+	if err != nil {
+		return nil, nil, err
+	}
+	selector := map[string]string{apitypes.LabelService: id}
+	podLabels := map[string]string{apitypes.LabelService: id}
+	for k, v := range pod.Labels {
+		podLabels[k] = v
+	}
+	// The Pods of service aren't workers.
+	delete(podLabels, apitypes.LabelManagedWorker)
+	pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	container := &pod.Spec.Containers[0]
+	for _, port := range ws.Ports {
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: port,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+	if ws.ReadinessPath != "" {
+		container.ReadinessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: ws.ReadinessPath,
+					Port: intstr.FromInt(int(ws.Ports[0])),
+				},
+			},
+		}
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        id,
+			Namespace:   pod.Namespace,
+			Labels:      podLabels,
+			Annotations: pod.Annotations,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ws.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: pod.Annotations,
+				},
+				Spec: pod.Spec,
+			},
+		},
+	}
+	if len(ws.Ports) == 0 {
+		return deployment, nil, nil
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      id,
+			Namespace: pod.Namespace,
+			Labels:    podLabels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+		},
+	}
+	for _, port := range ws.Ports {
+		service.Spec.Ports = append(service.Spec.Ports, corev1.ServicePort{
+			Name:       fmt.Sprintf("port-%d", port),
+			Port:       port,
+			TargetPort: intstr.FromInt(int(port)),
+			Protocol:   corev1.ProtocolTCP,
+		})
+	}
+
+	return deployment, service, nil
+}
+
+// TranslateServiceStatus aggregates the status of Deployment, Service and Pods into ServiceStatus. The Service is
+// nil if the service has no port.
+func TranslateServiceStatus(deployment *appsv1.Deployment, service *corev1.Service, pods []corev1.Pod) *v1types.ServiceStatus {
+	serviceStatus := &v1types.ServiceStatus{
+		Id:                deployment.GetName(),
+		Namespace:         deployment.GetNamespace(),
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		UpdatedReplicas:   deployment.Status.UpdatedReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		Conditions:        []v1types.ServiceCondition{},
+		Workers:           []v1types.WorkerSummary{},
+	}
+	if deployment.Spec.Replicas != nil {
+		serviceStatus.Replicas = *deployment.Spec.Replicas
+	}
+	if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+		serviceStatus.Image = containers[0].Image
+	}
+	serviceStatus.Ready = deployment.Status.ObservedGeneration >= deployment.GetGeneration() &&
+		deployment.Status.Replicas == serviceStatus.Replicas &&
+		deployment.Status.UpdatedReplicas == serviceStatus.Replicas &&
+		deployment.Status.ReadyReplicas == serviceStatus.Replicas
+	for _, condition := range deployment.Status.Conditions {
+		serviceStatus.Conditions = append(serviceStatus.Conditions, v1types.ServiceCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time,
+		})
+	}
+	if service != nil {
+		serviceStatus.ClusterIP = service.Spec.ClusterIP
+		for _, port := range service.Spec.Ports {
+			serviceStatus.Ports = append(serviceStatus.Ports, port.Port)
+		}
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].GetCreationTimestamp().Time.Before(pods[j].GetCreationTimestamp().Time)
+	})
+	for i := range pods {
+		serviceStatus.Workers = append(serviceStatus.Workers, TranslatePodSummary(&pods[i]))
+	}
+
+	return serviceStatus
+}
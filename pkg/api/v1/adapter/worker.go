@@ -0,0 +1,131 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/jinghzhu/kservice/pkg/config"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// InitWorker inits the Worker custom resource from the WorkerPod in JSON body. The WorkerPod is validated in the same
+// way as InitWorkerPod but is kept as it is in the spec, so that the defaults are applied by the controller.
+func InitWorker(ctx context.Context, jsonBody io.ReadCloser) (*v1types.Worker, error) {
+	body, err := ioutil.ReadAll(jsonBody)
+	if err != nil {
+		return nil, err
+	}
+	wp, err := InitWorkerPod(ctx, ioutil.NopCloser(bytes.NewReader(body)))
+	if err != nil {
+		return nil, err
+	}
+	if err = rejectPodOnlyFields(wp); err != nil {
+		return nil, err
+	}
+	g := config.GetConfig()
+	worker := &v1types.Worker{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apitypes.WorkerResource.GroupVersion().String(),
+			Kind:       apitypes.WorkerCRDKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: g.CRDNamePrefix + "-",
+			Namespace:    g.CRDNamespace,
+		},
+	}
+	if err = json.Unmarshal(body, &worker.Spec); err != nil {
+		return nil, err
+	}
+
+	return worker, nil
+}
+
+// TranslateWorkerToPod translates the Worker custom resource to Kubernetes Pod. The defaults of WorkerPod are
+// applied to the spec and the Pod is named after the Worker, so that one Worker never has more than one Pod. The Pod
+// is owned by the Worker if they're in the same namespace, since the owner in another namespace is taken as absent
+// by the garbage collector.
+func TranslateWorkerToPod(ctx context.Context, worker *v1types.Worker) (*corev1.Pod, error) {
+	spec, err := json.Marshal(&worker.Spec)
+	if err != nil {
+		return nil, err
+	}
+	wp, err := InitWorkerPod(ctx, ioutil.NopCloser(bytes.NewReader(spec)))
+	if err != nil {
+		return nil, err
+	}
+	if wp.Namespace == "" {
+		wp.Namespace = config.GetConfig().WorkerNamespace
+	}
+	if !config.GetConfig().IsNamespaceAllowed(wp.Namespace) {
+		return nil, fmt.Errorf("namespace %s is not allowed", wp.Namespace)
+	}
+	pod, err := TranslateWorkerPodToPod(ctx, wp)
+	if err != nil {
+		return nil, err
+	}
+	pod.GenerateName, pod.Name = "", worker.GetName()
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[apitypes.LabelWorker] = worker.GetName()
+	if pod.Namespace == worker.GetNamespace() {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: apitypes.WorkerResource.GroupVersion().String(),
+			Kind:       apitypes.WorkerCRDKind,
+			Name:       worker.GetName(),
+			UID:        worker.GetUID(),
+			Controller: &controller,
+		}}
+	}
+
+	return pod, nil
+}
+
+// IsPodOfWorker returns true if the Pod is created for the Worker, which is owned by it or has the Worker label.
+func IsPodOfWorker(worker *v1types.Worker, pod *corev1.Pod) bool {
+	for _, ref := range pod.GetOwnerReferences() {
+		if ref.Kind == apitypes.WorkerCRDKind && ref.UID == worker.GetUID() {
+			return true
+		}
+	}
+
+	return pod.GetLabels()[apitypes.LabelWorker] == worker.GetName()
+}
+
+// TranslateUnstructuredToWorker translates the unstructured object returned by dynamic client to Worker.
+func TranslateUnstructuredToWorker(obj *unstructured.Unstructured) (*v1types.Worker, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	worker := &v1types.Worker{}
+	if err = json.Unmarshal(data, worker); err != nil {
+		return nil, err
+	}
+
+	return worker, nil
+}
+
+// TranslateWorkerToUnstructured translates the Worker to the unstructured object used by dynamic client.
+func TranslateWorkerToUnstructured(worker *v1types.Worker) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(worker)
+	if err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{}
+	if err = obj.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,464 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"time"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// workflowSpecKey and workflowStatusKey are the keys of workflow ConfigMap data.
+	workflowSpecKey   string = "spec"
+	workflowStatusKey string = "status"
+	// maxStepNameLength keeps the step Pod name, which is <workflow ID>-<step>-<attempt>, a valid host name.
+	maxStepNameLength int = 40
+)
+
+var (
+	stepNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// stepRefRegexp matches {{steps.<name>.exitCode}} and {{steps.<name>.outputs.<key>}}.
+	stepRefRegexp = regexp.MustCompile(`\{\{\s*steps\.([a-z0-9-]+)\.(exitCode|outputs\.([A-Za-z0-9_.-]+))\s*\}\}`)
+)
+
+// InitWorkflow parses the Workflow and validates its steps, dependencies, run conditions and references.
+func InitWorkflow(ctx context.Context, jsonBody io.ReadCloser) (*v1types.Workflow, error) {
+	wf := &v1types.Workflow{}
+	if err := json.NewDecoder(jsonBody).Decode(wf); err != nil {
+		return wf, err
+	}
+	if len(wf.Steps) == 0 {
+		return wf, errors.New("steps is a mandatory parameter")
+	}
+	steps := make(map[string]*v1types.WorkflowStep)
+	for i := range wf.Steps {
+		step := &wf.Steps[i]
+		if len(step.Name) > maxStepNameLength || !stepNameRegexp.MatchString(step.Name) {
+			return wf, fmt.Errorf("step name %s should be a DNS label of at most %d characters", step.Name,
+				maxStepNameLength)
+		}
+		if _, ok := steps[step.Name]; ok {
+			return wf, fmt.Errorf("step %s is duplicated", step.Name)
+		}
+		steps[step.Name] = step
+		switch step.When {
+		case "":
+			step.When = apitypes.StepWhenSucceeded
+		case apitypes.StepWhenSucceeded, apitypes.StepWhenFailed, apitypes.StepWhenAlways:
+		default:
+			return wf, fmt.Errorf("when of step %s should be one of %s, %s and %s", step.Name,
+				apitypes.StepWhenSucceeded, apitypes.StepWhenFailed, apitypes.StepWhenAlways)
+		}
+		if _, err := translateStepWorker(ctx, step); err != nil {
+			return wf, fmt.Errorf("invalid worker of step %s: %v", step.Name, err)
+		}
+	}
+	for _, step := range wf.Steps {
+		for _, dep := range step.Dependencies {
+			if _, ok := steps[dep]; !ok {
+				return wf, fmt.Errorf("dependency %s of step %s doesn't exist", dep, step.Name)
+			}
+		}
+		for dep := range step.ExitCodes {
+			if !containsString(step.Dependencies, dep) {
+				return wf, fmt.Errorf("exitCodes of step %s refers to %s which is not its dependency", step.Name, dep)
+			}
+		}
+	}
+	ancestors := make(map[string]map[string]bool)
+	for _, step := range wf.Steps {
+		if _, err := stepAncestors(steps, step.Name, ancestors, map[string]bool{}); err != nil {
+			return wf, err
+		}
+	}
+	for _, step := range wf.Steps {
+		for _, ref := range stepRefs(&step) {
+			if !ancestors[step.Name][ref] {
+				return wf, fmt.Errorf("step %s refers to %s which is not its ancestor", step.Name, ref)
+			}
+		}
+	}
+
+	return wf, nil
+}
+
+// stepAncestors returns all steps the step depends on directly or indirectly, and fails if there is a cycle.
+func stepAncestors(steps map[string]*v1types.WorkflowStep, name string, ancestors map[string]map[string]bool,
+	visiting map[string]bool) (map[string]bool, error) {
+	if result, ok := ancestors[name]; ok {
+		return result, nil
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("dependencies of step %s have a cycle", name)
+	}
+	visiting[name] = true
+	result := make(map[string]bool)
+	for _, dep := range steps[name].Dependencies {
+		result[dep] = true
+		depAncestors, err := stepAncestors(steps, dep, ancestors, visiting)
+		if err != nil {
+			return nil, err
+		}
+		for ancestor := range depAncestors {
+			result[ancestor] = true
+		}
+	}
+	ancestors[name] = result
+
+	return result, nil
+}
+
+// stepRefs returns the names of steps referred by the Cmd and Env of step.
+func stepRefs(step *v1types.WorkflowStep) []string {
+	refs := []string{}
+	values := append([]string{}, step.Worker.Cmd...)
+	for _, v := range step.Worker.Env {
+		values = append(values, v)
+	}
+	for _, v := range values {
+		for _, match := range stepRefRegexp.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, match[1])
+		}
+	}
+
+	return refs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// translateStepWorker applies the defaults of WorkerPod to the worker of step.
+func translateStepWorker(ctx context.Context, step *v1types.WorkflowStep) (*v1types.WorkerPod, error) {
+	spec, err := json.Marshal(&step.Worker)
+	if err != nil {
+		return nil, err
+	}
+
+	wp, err := InitWorkerPod(ctx, ioutil.NopCloser(bytes.NewReader(spec)))
+	if err != nil {
+		return wp, err
+	}
+
+	return wp, rejectPodOnlyFields(wp)
+}
+
+// NewWorkflowStatus returns the status of workflow which is just created.
+func NewWorkflowStatus(id, namespace string, wf *v1types.Workflow) *v1types.WorkflowStatus {
+	status := &v1types.WorkflowStatus{
+		Id:           id,
+		Namespace:    namespace,
+		Phase:        apitypes.WorkflowPhaseRunning,
+		CreationTime: time.Now(),
+		Steps:        []v1types.StepStatus{},
+	}
+	for _, step := range wf.Steps {
+		status.Steps = append(status.Steps, v1types.StepStatus{
+			Name:    step.Name,
+			Phase:   apitypes.StepPhasePending,
+			Attempt: 1,
+		})
+	}
+
+	return status
+}
+
+// WorkflowID returns the workflow ID of the request.
+func WorkflowID(ctx context.Context) string {
+	return "workflow-" + requestIDPrefix(ctx)
+}
+
+// TranslateWorkflowToConfigMap translates the workflow spec and status to the ConfigMap which persists them.
+func TranslateWorkflowToConfigMap(wf *v1types.Workflow, status *v1types.WorkflowStatus) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      status.Id,
+			Namespace: status.Namespace,
+			Labels: map[string]string{
+				apitypes.LabelWorkflow: status.Id,
+			},
+		},
+		Data: make(map[string]string),
+	}
+	spec, err := json.Marshal(wf)
+	if err != nil {
+		return nil, err
+	}
+	cm.Data[workflowSpecKey] = string(spec)
+
+	return cm, SetConfigMapWorkflowStatus(cm, status)
+}
+
+// SetConfigMapWorkflowStatus writes the workflow status into the ConfigMap and labels it with the workflow phase.
+func SetConfigMapWorkflowStatus(cm *corev1.ConfigMap, status *v1types.WorkflowStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[workflowStatusKey] = string(data)
+	if cm.Labels == nil {
+		cm.Labels = make(map[string]string)
+	}
+	cm.Labels[apitypes.LabelWorkflowPhase] = status.Phase
+
+	return nil
+}
+
+// TranslateConfigMapToWorkflow parses the workflow spec and status from the ConfigMap.
+func TranslateConfigMapToWorkflow(cm *corev1.ConfigMap) (*v1types.Workflow, *v1types.WorkflowStatus, error) {
+	wf, status := &v1types.Workflow{}, &v1types.WorkflowStatus{}
+	if err := json.Unmarshal([]byte(cm.Data[workflowSpecKey]), wf); err != nil {
+		return nil, nil, fmt.Errorf("invalid spec of workflow %s: %v", cm.GetName(), err)
+	}
+	if err := json.Unmarshal([]byte(cm.Data[workflowStatusKey]), status); err != nil {
+		return nil, nil, fmt.Errorf("invalid status of workflow %s: %v", cm.GetName(), err)
+	}
+	if len(status.Steps) != len(wf.Steps) {
+		return nil, nil, fmt.Errorf("status of workflow %s doesn't match its steps", cm.GetName())
+	}
+
+	return wf, status, nil
+}
+
+// TranslateStepToPod translates the workflow step to the Pod of its current attempt. The references to the results
+// of dependencies are replaced by their values.
+func TranslateStepToPod(ctx context.Context, status *v1types.WorkflowStatus, step *v1types.WorkflowStep,
+	stepStatus *v1types.StepStatus) (*corev1.Pod, error) {
+	wp, err := translateStepWorker(ctx, step)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]*v1types.StepStatus)
+	for i := range status.Steps {
+		results[status.Steps[i].Name] = &status.Steps[i]
+	}
+	replace := func(v string) string {
+		return stepRefRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+			match := stepRefRegexp.FindStringSubmatch(ref)
+			result, ok := results[match[1]]
+			if !ok {
+				return ""
+			}
+			if match[2] == "exitCode" {
+				if result.ExitCode == nil {
+					return ""
+				}
+				return strconv.Itoa(int(*result.ExitCode))
+			}
+			return result.Outputs[match[3]]
+		})
+	}
+	cmd := make([]string, 0, len(wp.Cmd))
+	for _, v := range wp.Cmd {
+		cmd = append(cmd, replace(v))
+	}
+	wp.Cmd = cmd
+	env := make(map[string]string)
+	for k, v := range wp.Env {
+		env[k] = replace(v)
+	}
+	wp.Env = env
+	wp.Namespace = status.Namespace
+	pod, err := TranslateWorkerPodToPod(ctx, wp)
+	if err != nil {
+		return nil, err
+	}
+	pod.GenerateName = ""
+	pod.Name = StepPodName(status.Id, stepStatus)
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	pod.Labels[apitypes.LabelWorkflow] = status.Id
+	pod.Labels[apitypes.LabelWorkflowStep] = step.Name
+
+	return pod, nil
+}
+
+// StepPodName returns the name of Pod for the current attempt of step.
+func StepPodName(workflowID string, stepStatus *v1types.StepStatus) string {
+	return fmt.Sprintf("%s-%s-%d", workflowID, stepStatus.Name, stepStatus.Attempt)
+}
+
+// UpdateStepStatus updates the step status by its Pod. A nil Pod means it's deleted.
+func UpdateStepStatus(stepStatus *v1types.StepStatus, pod *corev1.Pod) {
+	if pod == nil {
+		now := time.Now()
+		stepStatus.Phase = apitypes.StepPhaseFailed
+		stepStatus.Reason, stepStatus.Message = apitypes.WorkerStatusReasonCancelled, "Pod is deleted"
+		stepStatus.FinishTime = &now
+		return
+	}
+	workerStatus := TranslatePodStatus(pod)
+	stepStatus.StartTime = workerStatus.StartTime
+	stepStatus.Reason, stepStatus.Message = workerStatus.Reason, workerStatus.Message
+	if workerStatus.State != apitypes.WorkerStatusTerminated {
+		return
+	}
+	stepStatus.ExitCode = workerStatus.ExitCode
+	stepStatus.FinishTime = workerStatus.FinishTime
+	if stepStatus.FinishTime == nil {
+		now := time.Now()
+		stepStatus.FinishTime = &now
+	}
+	outputs := make(map[string]string)
+	if json.Unmarshal([]byte(workerStatus.Message), &outputs) == nil {
+		stepStatus.Outputs, stepStatus.Message = outputs, ""
+	}
+	if workerStatus.ExitCode != nil && *workerStatus.ExitCode == 0 {
+		stepStatus.Phase = apitypes.StepPhaseSucceeded
+	} else {
+		stepStatus.Phase = apitypes.StepPhaseFailed
+	}
+}
+
+// UpdateQueuedStepStatus updates the running step whose Pod is held in admission queue. The step fails if its Pod
+// can't be created.
+func UpdateQueuedStepStatus(stepStatus *v1types.StepStatus, cm *corev1.ConfigMap) {
+	workerStatus := TranslateQueuedStatus(cm)
+	if workerStatus == nil {
+		return
+	}
+	stepStatus.Reason, stepStatus.Message = workerStatus.Reason, workerStatus.Message
+	if workerStatus.Status == apitypes.QueuePhaseFailed {
+		now := time.Now()
+		stepStatus.Phase, stepStatus.FinishTime = apitypes.StepPhaseFailed, &now
+	}
+}
+
+// IsStepFinished returns true if the step is in a final phase.
+func IsStepFinished(stepStatus *v1types.StepStatus) bool {
+	switch stepStatus.Phase {
+	case apitypes.StepPhaseSucceeded, apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped:
+		return true
+	}
+
+	return false
+}
+
+// EvaluateStep returns whether all dependencies of the pending step are finished and, if so, whether its run
+// condition is met.
+func EvaluateStep(step *v1types.WorkflowStep, status *v1types.WorkflowStatus) (ready bool, run bool) {
+	results := make(map[string]*v1types.StepStatus)
+	for i := range status.Steps {
+		results[status.Steps[i].Name] = &status.Steps[i]
+	}
+	anyFailed, allSucceeded := false, true
+	for _, dep := range step.Dependencies {
+		result := results[dep]
+		if result == nil || !IsStepFinished(result) {
+			return false, false
+		}
+		anyFailed = anyFailed || result.Phase == apitypes.StepPhaseFailed
+		allSucceeded = allSucceeded && result.Phase == apitypes.StepPhaseSucceeded
+	}
+	switch step.When {
+	case apitypes.StepWhenFailed:
+		run = anyFailed
+	case apitypes.StepWhenAlways:
+		run = true
+	default:
+		run = allSucceeded
+	}
+	for dep, exitCodes := range step.ExitCodes {
+		result := results[dep]
+		matched := false
+		for _, exitCode := range exitCodes {
+			matched = matched || (result.ExitCode != nil && *result.ExitCode == exitCode)
+		}
+		run = run && matched
+	}
+
+	return true, run
+}
+
+// UpdateWorkflowPhase sets the phase of workflow after all steps are finished. It returns true if the phase is
+// changed.
+func UpdateWorkflowPhase(status *v1types.WorkflowStatus) bool {
+	phase := apitypes.WorkflowPhaseSucceeded
+	for i := range status.Steps {
+		if !IsStepFinished(&status.Steps[i]) {
+			return false
+		}
+		if status.Steps[i].Phase == apitypes.StepPhaseFailed {
+			phase = apitypes.WorkflowPhaseFailed
+		}
+	}
+	now := time.Now()
+	status.Phase, status.FinishTime = phase, &now
+
+	return true
+}
+
+// RetryWorkflowStep resets the failed step and all its descendants which haven't succeeded, so that they run again
+// with a new attempt while the succeeded steps are kept.
+func RetryWorkflowStep(wf *v1types.Workflow, status *v1types.WorkflowStatus, name string) error {
+	var target *v1types.StepStatus
+	for i := range status.Steps {
+		if status.Steps[i].Name == name {
+			target = &status.Steps[i]
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("step %s doesn't exist", name)
+	}
+	if target.Phase != apitypes.StepPhaseFailed {
+		return fmt.Errorf("step %s is %s but only failed step can be retried", name, target.Phase)
+	}
+	reset := map[string]bool{name: true}
+	for changed := true; changed; {
+		changed = false
+		for _, step := range wf.Steps {
+			if reset[step.Name] {
+				continue
+			}
+			for _, dep := range step.Dependencies {
+				if reset[dep] {
+					reset[step.Name], changed = true, true
+					break
+				}
+			}
+		}
+	}
+	for i := range status.Steps {
+		if reset[status.Steps[i].Name] && status.Steps[i].Phase == apitypes.StepPhaseRunning {
+			return fmt.Errorf("step %s depending on %s is still running", status.Steps[i].Name, name)
+		}
+	}
+	for i := range status.Steps {
+		stepStatus := &status.Steps[i]
+		if !reset[stepStatus.Name] || stepStatus.Phase == apitypes.StepPhaseSucceeded {
+			continue
+		}
+		if stepStatus.Worker != "" {
+			stepStatus.Attempt++
+		}
+		*stepStatus = v1types.StepStatus{
+			Name:    stepStatus.Name,
+			Phase:   apitypes.StepPhasePending,
+			Attempt: stepStatus.Attempt,
+		}
+	}
+	status.Phase, status.FinishTime = apitypes.WorkflowPhaseRunning, nil
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package adapter
+
+import (
+	"testing"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+)
+
+func exitCode(code int32) v1types.ExitCode {
+	return &code
+}
+
+func TestEvaluateStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    v1types.WorkflowStep
+		results []v1types.StepStatus
+		ready   bool
+		run     bool
+	}{
+		{
+			name:  "no dependency",
+			step:  v1types.WorkflowStep{Name: "a", When: apitypes.StepWhenSucceeded},
+			ready: true,
+			run:   true,
+		},
+		{
+			name: "dependency is running",
+			step: v1types.WorkflowStep{Name: "b", Dependencies: []string{"a"}, When: apitypes.StepWhenAlways},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseRunning},
+			},
+		},
+		{
+			name: "dependency doesn't exist",
+			step: v1types.WorkflowStep{Name: "b", Dependencies: []string{"x"}, When: apitypes.StepWhenAlways},
+		},
+		{
+			name: "all dependencies succeed",
+			step: v1types.WorkflowStep{Name: "c", Dependencies: []string{"a", "b"}, When: apitypes.StepWhenSucceeded},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseSucceeded},
+				{Name: "b", Phase: apitypes.StepPhaseSucceeded},
+			},
+			ready: true,
+			run:   true,
+		},
+		{
+			name: "skipped dependency doesn't succeed",
+			step: v1types.WorkflowStep{Name: "c", Dependencies: []string{"a", "b"}, When: apitypes.StepWhenSucceeded},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseSucceeded},
+				{Name: "b", Phase: apitypes.StepPhaseSkipped},
+			},
+			ready: true,
+		},
+		{
+			name: "run on failure",
+			step: v1types.WorkflowStep{Name: "c", Dependencies: []string{"a", "b"}, When: apitypes.StepWhenFailed},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseSucceeded},
+				{Name: "b", Phase: apitypes.StepPhaseFailed},
+			},
+			ready: true,
+			run:   true,
+		},
+		{
+			name: "skip on failure if nothing fails",
+			step: v1types.WorkflowStep{Name: "c", Dependencies: []string{"a"}, When: apitypes.StepWhenFailed},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseSucceeded},
+			},
+			ready: true,
+		},
+		{
+			name: "always run",
+			step: v1types.WorkflowStep{Name: "c", Dependencies: []string{"a"}, When: apitypes.StepWhenAlways},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseFailed},
+			},
+			ready: true,
+			run:   true,
+		},
+		{
+			name: "exit code matches",
+			step: v1types.WorkflowStep{Name: "b", Dependencies: []string{"a"}, When: apitypes.StepWhenAlways,
+				ExitCodes: map[string][]int32{"a": {1, 2}}},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseFailed, ExitCode: exitCode(2)},
+			},
+			ready: true,
+			run:   true,
+		},
+		{
+			name: "exit code doesn't match",
+			step: v1types.WorkflowStep{Name: "b", Dependencies: []string{"a"}, When: apitypes.StepWhenAlways,
+				ExitCodes: map[string][]int32{"a": {1, 2}}},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseFailed, ExitCode: exitCode(3)},
+			},
+			ready: true,
+		},
+		{
+			name: "skipped dependency has no exit code",
+			step: v1types.WorkflowStep{Name: "b", Dependencies: []string{"a"}, When: apitypes.StepWhenAlways,
+				ExitCodes: map[string][]int32{"a": {0}}},
+			results: []v1types.StepStatus{
+				{Name: "a", Phase: apitypes.StepPhaseSkipped},
+			},
+			ready: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := &v1types.WorkflowStatus{Steps: test.results}
+			ready, run := EvaluateStep(&test.step, status)
+			if ready != test.ready || run != test.run {
+				t.Errorf("EvaluateStep() = (%v, %v), want (%v, %v)", ready, run, test.ready, test.run)
+			}
+		})
+	}
+}
+
+func TestStepAncestors(t *testing.T) {
+	tests := []struct {
+		name      string
+		deps      map[string][]string
+		step      string
+		ancestors []string
+		cycle     bool
+	}{
+		{
+			name: "root",
+			deps: map[string][]string{"a": nil},
+			step: "a",
+		},
+		{
+			name:      "chain",
+			deps:      map[string][]string{"a": nil, "b": {"a"}, "c": {"b"}},
+			step:      "c",
+			ancestors: []string{"a", "b"},
+		},
+		{
+			name:      "diamond",
+			deps:      map[string][]string{"a": nil, "b": {"a"}, "c": {"a"}, "d": {"b", "c"}},
+			step:      "d",
+			ancestors: []string{"a", "b", "c"},
+		},
+		{
+			name:  "self cycle",
+			deps:  map[string][]string{"a": {"a"}},
+			step:  "a",
+			cycle: true,
+		},
+		{
+			name:  "cycle",
+			deps:  map[string][]string{"a": {"c"}, "b": {"a"}, "c": {"b"}},
+			step:  "a",
+			cycle: true,
+		},
+		{
+			name:  "cycle among ancestors",
+			deps:  map[string][]string{"a": {"b"}, "b": {"a"}, "c": {"a"}},
+			step:  "c",
+			cycle: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps := make(map[string]*v1types.WorkflowStep)
+			for name, deps := range test.deps {
+				steps[name] = &v1types.WorkflowStep{Name: name, Dependencies: deps}
+			}
+			ancestors, err := stepAncestors(steps, test.step, map[string]map[string]bool{}, map[string]bool{})
+			if test.cycle {
+				if err == nil {
+					t.Fatalf("stepAncestors() = %v, want cycle error", ancestors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stepAncestors() fails: %v", err)
+			}
+			if len(ancestors) != len(test.ancestors) {
+				t.Fatalf("stepAncestors() = %v, want %v", ancestors, test.ancestors)
+			}
+			for _, ancestor := range test.ancestors {
+				if !ancestors[ancestor] {
+					t.Errorf("stepAncestors() = %v, want %v", ancestors, test.ancestors)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryWorkflowStep(t *testing.T) {
+	// a -> b -> c and a -> d, while e is independent.
+	wf := &v1types.Workflow{Steps: []v1types.WorkflowStep{
+		{Name: "a"},
+		{Name: "b", Dependencies: []string{"a"}},
+		{Name: "c", Dependencies: []string{"b"}},
+		{Name: "d", Dependencies: []string{"a"}, When: apitypes.StepWhenFailed},
+		{Name: "e"},
+	}}
+	tests := []struct {
+		name     string
+		retry    string
+		phases   []string
+		want     []string
+		attempts []int
+		wantErr  bool
+	}{
+		{
+			name:  "reset failed step and its skipped descendants",
+			retry: "a",
+			phases: []string{apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped, apitypes.StepPhaseSkipped,
+				apitypes.StepPhaseSucceeded, apitypes.StepPhaseFailed},
+			want: []string{apitypes.StepPhasePending, apitypes.StepPhasePending, apitypes.StepPhasePending,
+				apitypes.StepPhaseSucceeded, apitypes.StepPhaseFailed},
+			attempts: []int{2, 1, 1, 1, 1},
+		},
+		{
+			name:  "reset failed descendant",
+			retry: "b",
+			phases: []string{apitypes.StepPhaseSucceeded, apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped,
+				apitypes.StepPhaseSkipped, apitypes.StepPhaseSucceeded},
+			want: []string{apitypes.StepPhaseSucceeded, apitypes.StepPhasePending, apitypes.StepPhasePending,
+				apitypes.StepPhaseSkipped, apitypes.StepPhaseSucceeded},
+			attempts: []int{1, 2, 1, 1, 1},
+		},
+		{
+			name:  "descendant is running",
+			retry: "a",
+			phases: []string{apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped, apitypes.StepPhaseSkipped,
+				apitypes.StepPhaseRunning, apitypes.StepPhaseSucceeded},
+			wantErr: true,
+		},
+		{
+			name:  "step isn't failed",
+			retry: "e",
+			phases: []string{apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped, apitypes.StepPhaseSkipped,
+				apitypes.StepPhaseSucceeded, apitypes.StepPhaseSucceeded},
+			wantErr: true,
+		},
+		{
+			name:  "step doesn't exist",
+			retry: "x",
+			phases: []string{apitypes.StepPhaseFailed, apitypes.StepPhaseSkipped, apitypes.StepPhaseSkipped,
+				apitypes.StepPhaseSucceeded, apitypes.StepPhaseSucceeded},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := &v1types.WorkflowStatus{Phase: apitypes.WorkflowPhaseFailed}
+			for i, step := range wf.Steps {
+				stepStatus := v1types.StepStatus{Name: step.Name, Phase: test.phases[i], Attempt: 1}
+				// The skipped step never has a Pod.
+				if stepStatus.Phase != apitypes.StepPhaseSkipped {
+					stepStatus.Worker = step.Name + "-1"
+				}
+				status.Steps = append(status.Steps, stepStatus)
+			}
+			err := RetryWorkflowStep(wf, status, test.retry)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("RetryWorkflowStep() succeeds, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RetryWorkflowStep() fails: %v", err)
+			}
+			if status.Phase != apitypes.WorkflowPhaseRunning || status.FinishTime != nil {
+				t.Errorf("workflow is %s, want %s", status.Phase, apitypes.WorkflowPhaseRunning)
+			}
+			for i, stepStatus := range status.Steps {
+				if stepStatus.Phase != test.want[i] || stepStatus.Attempt != test.attempts[i] {
+					t.Errorf("step %s is %s of attempt %d, want %s of attempt %d", stepStatus.Name,
+						stepStatus.Phase, stepStatus.Attempt, test.want[i], test.attempts[i])
+				}
+				if stepStatus.Phase == apitypes.StepPhasePending && stepStatus.Worker != "" {
+					t.Errorf("step %s still has worker %s", stepStatus.Name, stepStatus.Worker)
+				}
+			}
+		})
+	}
+}
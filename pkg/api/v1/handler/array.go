@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// listArrayPods returns the Pods of worker array and the names of the ones held in admission queue, which are
+// Pending since their Pods aren't created yet.
+func listArrayPods(ctx context.Context, namespace, arrayID string) ([]corev1.Pod, map[string]bool, error) {
+	selector := labels.SelectorFromSet(labels.Set{apitypes.LabelArray: arrayID}).String()
+	pods, err := apitypes.DefaultPodClient().ListPods(namespace, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, err
+	}
+	cms, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelQueuedWorker + "," + selector,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	created := make(map[string]bool)
+	for i := range pods.Items {
+		created[pods.Items[i].GetName()] = true
+	}
+	queued := make(map[string]bool)
+	for i := range cms.Items {
+		_, pod, err := adapter.TranslateConfigMapToQueuedWorker(&cms.Items[i])
+		if err != nil || created[pod.GetName()] {
+			continue
+		}
+		pod.Status.Phase, pod.Status.Reason = corev1.PodPending, apitypes.WorkerStatusQueued
+		pods.Items = append(pods.Items, *pod)
+		queued[pod.GetName()] = true
+	}
+
+	return pods.Items, queued, nil
+}
+
+// createArrayPod creates the Pod of array, or holds it in admission queue if queue is true.
+func createArrayPod(ctx context.Context, podObj *corev1.Pod, queue bool) (string, error) {
+	if !queue {
+		pod, err := apitypes.DefaultKubeClient().CoreV1().Pods(podObj.GetNamespace()).Create(ctx, podObj,
+			metav1.CreateOptions{})
+		if err != nil {
+			return "", err
+		}
+		return pod.GetName(), nil
+	}
+	cm, err := adapter.TranslatePodToQueuedConfigMap(podObj)
+	if err != nil {
+		return "", err
+	}
+	_, err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(cm.GetNamespace()).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	return cm.GetName(), nil
+}
+
+// deleteArrayPod deletes the Pod of array, or drops it from admission queue if it's queued.
+func deleteArrayPod(ctx context.Context, namespace, name string, queued bool) error {
+	if queued {
+		return dropQueuedPod(ctx, namespace, name)
+	}
+
+	return apitypes.DefaultKubeClient().CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+// CreateArray fans out one submission into the array of workers by index range or parameter matrix. The workers
+// which are already created are deleted if any of them fails to be created.
+func CreateArray(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create worker array", logFields)
+	if r.Body == nil {
+		errMsg := "No POST parameters found in the request"
+		logger.Error(errMsg)
+
+		return result, 400, errors.New(errMsg)
+	}
+	wa, err := adapter.InitWorkerArray(ctx, r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if err = resolveWorkerNamespace(r, &wa.WorkerPod); err != nil {
+		logFields[apitypes.LogWorkerNamespace] = wa.Namespace
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	arrayID, podObjs, err := adapter.TranslateWorkerArrayToPods(ctx, wa)
+	if err != nil {
+		errMsg := "Fail to init Pod objects"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	logFields[apitypes.LogWorkerName], logFields[apitypes.LogWorkerNamespace] = arrayID, wa.Namespace
+
+	arrayDetails := &v1types.ArrayDetails{
+		Id:      arrayID,
+		Size:    len(podObjs),
+		Workers: []string{},
+	}
+	// The workers are held in admission queue if it's enabled.
+	queue := config.GetConfig().IsQueueEnabled()
+	if queue {
+		status = http.StatusAccepted
+	}
+	for _, podObj := range podObjs {
+		podName, err := createArrayPod(ctx, podObj, queue)
+		if err != nil {
+			errMsg := "Fail to create Pod"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+			for _, podName := range arrayDetails.Workers {
+				if err := deleteArrayPod(ctx, wa.Namespace, podName, queue); err != nil {
+					logger.ErrorFields("Fail to delete Pod of array", logger.Fields{
+						apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+						apitypes.LogWorkerName: podName,
+						logger.ERROR:           err,
+					})
+				}
+			}
+
+			return result, 400, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+		}
+		arrayDetails.Workers = append(arrayDetails.Workers, podName)
+	}
+	result, err = json.Marshal(arrayDetails)
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+	}
+
+	logger.InfoFields("Successfully create worker array", logFields)
+
+	return result, status, err
+}
+
+// GetArrayStatus retrieves the progress of worker array and the status of every worker.
+func GetArrayStatus(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	arrayID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: arrayID,
+	}
+	logger.InfoFields("Calling GetArrayStatus", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	pods, _, err := listArrayPods(ctx, namespace, arrayID)
+	if err != nil {
+		errMsg := "Fail to list Pods of array"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+	}
+	if len(pods) == 0 {
+		errMsg := "Fail to find worker array"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s", errMsg, arrayID)
+	}
+	result, err = json.Marshal(adapter.TranslateArrayStatus(arrayID, pods))
+	if err != nil {
+		errMsg := "Fail to marshal array status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+	}
+
+	logger.InfoFields("Successfully get array status", logFields)
+
+	return result, status, err
+}
+
+// CancelArray deletes the workers of array which are not terminated yet. The terminated workers are kept so that
+// their status and logs are still available.
+func CancelArray(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	arrayID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: arrayID,
+	}
+	logger.InfoFields("Calling CancelArray", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	pods, queued, err := listArrayPods(ctx, namespace, arrayID)
+	if err != nil {
+		errMsg := "Fail to list Pods of array"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+	}
+	if len(pods) == 0 {
+		errMsg := "Fail to find worker array"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s", errMsg, arrayID)
+	}
+	arrayStatus := adapter.TranslateArrayStatus(arrayID, pods)
+	arrayDetails := &v1types.ArrayDetails{
+		Id:      arrayID,
+		Size:    arrayStatus.Size,
+		Workers: []string{},
+	}
+	for _, element := range arrayStatus.Elements {
+		if element.Status == string(corev1.PodSucceeded) || element.Status == string(corev1.PodFailed) {
+			continue
+		}
+		if err = deleteArrayPod(ctx, namespace, element.Id, queued[element.Id]); err != nil {
+			errMsg := "Fail to delete Pod of array"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, element.Id, err)
+		}
+		arrayDetails.Workers = append(arrayDetails.Workers, element.Id)
+	}
+	result, err = json.Marshal(arrayDetails)
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, arrayID, err)
+	}
+
+	logger.InfoFields("Successfully cancel worker array", logFields)
+
+	return result, status, err
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// createDelayedPod persists the Pod which is created by the delayed worker controller at runAt. The ID in result is
+// the name of the Pod to be created.
+func createDelayedPod(ctx context.Context, podObj *corev1.Pod, runAt time.Time) (result []byte, status int, err error) {
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: podObj.GetNamespace(),
+		"runAt":                     runAt,
+	}
+	cm, err := adapter.TranslatePodToDelayedConfigMap(podObj, runAt)
+	if err != nil {
+		errMsg := "Fail to init delayed worker object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	logFields[apitypes.LogWorkerName] = cm.GetName()
+	_, err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(cm.GetNamespace()).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create delayed worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: cm.GetName()})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cm.GetName(), err)
+	}
+
+	logger.InfoFields("Successfully create delayed worker", logFields)
+
+	return result, http.StatusAccepted, err
+}
+
+// getDelayedConfigMap gets the ConfigMap of delayed worker.
+func getDelayedConfigMap(ctx context.Context, namespace, id string) (*corev1.ConfigMap, error) {
+	cm, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if cm.GetLabels()[apitypes.LabelDelayedWorker] != id {
+		return nil, fmt.Errorf("%s is not a delayed worker", id)
+	}
+
+	return cm, nil
+}
+
+// ListDelayedWorkers lists the delayed workers which are not created yet.
+func ListDelayedWorkers(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	namespace, err := workerNamespace(r)
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: namespace,
+	}
+	logger.InfoFields("Calling ListDelayedWorkers", logFields)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cms, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelDelayedWorker,
+	})
+	if err != nil {
+		errMsg := "Fail to list delayed workers"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	delayedList := &v1types.DelayedWorkerList{Items: []v1types.DelayedWorker{}}
+	for i := range cms.Items {
+		dw, _, err := adapter.TranslateConfigMapToDelayedWorker(&cms.Items[i])
+		if err != nil {
+			logFields[logger.ERROR] = err
+			logger.ErrorFields("Fail to parse delayed worker", logFields)
+			continue
+		}
+		delayedList.Items = append(delayedList.Items, *dw)
+	}
+	sort.SliceStable(delayedList.Items, func(i, j int) bool {
+		return delayedList.Items[i].RunAt.Before(delayedList.Items[j].RunAt)
+	})
+	result, err = json.Marshal(delayedList)
+	if err != nil {
+		errMsg := "Fail to marshal delayed worker list into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	logger.InfoFields("Successfully list delayed workers", logFields)
+
+	return result, status, err
+}
+
+// GetDelayedWorker retrieves the delayed worker. It's not found after its Pod is created.
+func GetDelayedWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	id := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: id,
+	}
+	logger.InfoFields("Calling GetDelayedWorker", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cm, err := getDelayedConfigMap(ctx, namespace, id)
+	if err != nil {
+		errMsg := "Fail to get delayed worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	dw, _, err := adapter.TranslateConfigMapToDelayedWorker(cm)
+	if err != nil {
+		errMsg := "Fail to parse delayed worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	result, err = json.Marshal(dw)
+	if err != nil {
+		errMsg := "Fail to marshal delayed worker into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+
+	logger.InfoFields("Successfully get delayed worker", logFields)
+
+	return result, status, err
+}
+
+// CancelDelayedWorker cancels the delayed worker which is not claimed yet, or deletes the failed one.
+func CancelDelayedWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	id := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: id,
+	}
+	logger.InfoFields("Calling CancelDelayedWorker", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cm, err := getDelayedConfigMap(ctx, namespace, id)
+	if err != nil {
+		errMsg := "Fail to get delayed worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	dw, _, err := adapter.TranslateConfigMapToDelayedWorker(cm)
+	if err == nil && dw.Phase == apitypes.DelayedPhaseFiring {
+		errMsg := "Delayed worker is being created"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 409, fmt.Errorf("%s for %s", errMsg, id)
+	}
+	// The precondition makes sure the delayed worker isn't claimed after it's read.
+	resourceVersion := cm.GetResourceVersion()
+	err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Delete(ctx, id, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if err != nil {
+		errMsg := "Fail to cancel delayed worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsConflict(err) {
+			status = 409
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: id})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+
+	logger.InfoFields("Successfully cancel delayed worker", logFields)
+
+	return result, status, err
+}
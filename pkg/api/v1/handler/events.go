@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// listPodEvents returns the events whose involved object is the given Pod.
+func listPodEvents(namespace, podName string) ([]corev1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": podName,
+	}.AsSelector().String()
+	events, err := apitypes.DefaultPodClient().GetEvents(namespace, podName, metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	return events.Items, nil
+}
+
+// GetPodEvents gets the Kubernetes events of worker.
+func GetPodEvents(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling GetPodEvents", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	events, err := listPodEvents(namespace, podName)
+	if err != nil {
+		errMsg := "Fail to get Pod events"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	result, err = json.Marshal(adapter.TranslateEvents(events))
+	if err != nil {
+		errMsg := "Fail to marshal Pod events into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logger.InfoFields("Successfully get Pod events", logFields)
+
+	return result, status, err
+}
+
+// DiagnosePod explains why the worker is in its current state, such as why it's pending, with suggested fix.
+func DiagnosePod(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling DiagnosePod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	events, err := listPodEvents(namespace, podName)
+	if err != nil {
+		errMsg := "Fail to get Pod events"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	diagnosis := adapter.DiagnosePod(pod, events)
+	result, err = json.Marshal(diagnosis)
+	if err != nil {
+		errMsg := "Fail to marshal Pod diagnosis into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logFields["problems"] = len(diagnosis.Problems)
+	logger.InfoFields("Successfully diagnose Pod", logFields)
+
+	return result, status, err
+}
@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	// maxExecOutput is the maximum bytes of stdout and stderr kept for each command.
+	maxExecOutput int = 1 << 20
+
+	defaultExecTimeout time.Duration = 60 * time.Second
+	maxExecTimeout     time.Duration = 10 * time.Minute
+)
+
+// execOptions is the options to run command inside the container of worker. The connection to the container is
+// closed when ctx is done.
+type execOptions struct {
+	ctx       context.Context
+	namespace string
+	podName   string
+	container string
+	cmd       []string
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+	tty       bool
+	sizeQueue remotecommand.TerminalSizeQueue
+}
+
+// cancelableUpgrader closes the SPDY connection once ctx is done, because the executor can't be canceled otherwise.
+// Closing the connection closes the streams of the command and makes the executor return.
+type cancelableUpgrader struct {
+	spdy.Upgrader
+	ctx  context.Context
+	done chan struct{}
+}
+
+func (u *cancelableUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.Upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-u.ctx.Done():
+			conn.Close()
+		case <-u.done:
+		}
+	}()
+
+	return conn, nil
+}
+
+// execInPod runs the command through the SPDY executor of Kubernetes. It returns the exit code of the command and
+// returns error only if the command can't be run or ctx is done before it exits.
+func execInPod(opts *execOptions) (int, error) {
+	ctx := opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req := apitypes.DefaultKubeClient().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(opts.namespace).
+		Name(opts.podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: opts.container,
+			Command:   opts.cmd,
+			Stdin:     opts.stdin != nil,
+			Stdout:    opts.stdout != nil,
+			Stderr:    opts.stderr != nil && !opts.tty,
+			TTY:       opts.tty,
+		}, scheme.ParameterCodec)
+	transport, spdyUpgrader, err := spdy.RoundTripperFor(apitypes.DefaultRestConfig())
+	if err != nil {
+		return 0, err
+	}
+	upgrader := &cancelableUpgrader{Upgrader: spdyUpgrader, ctx: ctx, done: make(chan struct{})}
+	defer close(upgrader.done)
+	executor, err := remotecommand.NewSPDYExecutorForTransports(transport, upgrader, http.MethodPost, req.URL())
+	if err != nil {
+		return 0, err
+	}
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:             opts.stdin,
+		Stdout:            opts.stdout,
+		Tty:               opts.tty,
+		TerminalSizeQueue: opts.sizeQueue,
+	}
+	if !opts.tty {
+		streamOpts.Stderr = opts.stderr
+	}
+	err = executor.Stream(streamOpts)
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if err != nil {
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.Exited() {
+			return exitErr.ExitStatus(), nil
+		}
+
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// getRunningPod returns the Pod and the name of target container if the container is running. If container is
+// empty, the worker container is used. The HTTP status is returned together with error.
+func getRunningPod(namespace, podName, container string) (*corev1.Pod, string, int, error) {
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, container, 404, fmt.Errorf("Fail to get Pod %s because of %v", podName, err)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return pod, container, 409, fmt.Errorf("Pod %s has no container", podName)
+	}
+	if container == "" {
+		container = pod.Spec.Containers[0].Name
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != container {
+			continue
+		}
+		if containerStatus.State.Running == nil {
+			return pod, container, 409, fmt.Errorf("container %s of %s is not running", container, podName)
+		}
+
+		return pod, container, http.StatusOK, nil
+	}
+
+	return pod, container, 409, fmt.Errorf("container %s of %s is not found or not started", container, podName)
+}
+
+// limitedBuffer keeps at most limit bytes and drops the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if left := b.limit - b.buf.Len(); left < len(p) {
+		b.truncated = true
+		if left > 0 {
+			b.buf.Write(p[:left])
+		}
+	} else {
+		b.buf.Write(p)
+	}
+
+	return len(p), nil
+}
+
+// ExecPod runs a command inside the running worker and returns its stdout, stderr and exit code. The query
+// parameter timeout limits how long the command can run. The connection to the container is closed on timeout or
+// when the client goes away, which closes the command's streams, but the command itself isn't killed in the
+// container.
+func ExecPod(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling ExecPod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	if r.Body == nil {
+		return result, 400, errors.New("No POST parameters found in the request")
+	}
+	execReq := &v1types.ExecRequest{}
+	if err = json.NewDecoder(r.Body).Decode(execReq); err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if len(execReq.Cmd) == 0 {
+		return result, 400, errors.New("cmd is a mandatory parameter")
+	}
+	timeout, err := queryDuration(r, "timeout", defaultExecTimeout)
+	if err != nil {
+		return result, 400, err
+	}
+	if timeout <= 0 || timeout > maxExecTimeout {
+		return result, 400, fmt.Errorf("timeout should be in range (0, %v]", maxExecTimeout)
+	}
+
+	_, container, status, err := getRunningPod(namespace, podName, execReq.Container)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to find running container", logFields)
+
+		return result, status, err
+	}
+	logFields["container"], logFields["cmd"] = container, execReq.Cmd
+
+	stdout := &limitedBuffer{limit: maxExecOutput}
+	stderr := &limitedBuffer{limit: maxExecOutput}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	opts := &execOptions{
+		ctx:       execCtx,
+		namespace: namespace,
+		podName:   podName,
+		container: container,
+		cmd:       execReq.Cmd,
+		stdout:    stdout,
+		stderr:    stderr,
+	}
+	if execReq.Stdin != "" {
+		opts.stdin = strings.NewReader(execReq.Stdin)
+	}
+	exitCode, err := execInPod(opts)
+	if err != nil && ctx.Err() != nil {
+		return result, http.StatusRequestTimeout, ctx.Err()
+	}
+	if err != nil && execCtx.Err() != nil {
+		err = fmt.Errorf("command in %s doesn't finish in %v", podName, timeout)
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Timeout to exec in Pod", logFields)
+
+		return result, http.StatusGatewayTimeout, err
+	}
+	if err != nil {
+		errMsg := "Fail to exec in Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	result, err = json.Marshal(&v1types.ExecResult{
+		Stdout:    stdout.buf.String(),
+		Stderr:    stderr.buf.String(),
+		ExitCode:  exitCode,
+		Truncated: stdout.truncated || stderr.truncated,
+	})
+	if err != nil {
+		errMsg := "Fail to marshal exec result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logFields["exitCode"] = exitCode
+	logger.InfoFields("Successfully exec in Pod", logFields)
+
+	return result, status, err
+}
+
+// ExecPodWebSocket runs a command inside the running worker and streams its stdout and stderr over WebSocket.
+// The command is given by repeated query parameter cmd. If stdin=true, stdin messages from the client are sent
+// to the command. An exit message with the exit code is sent before the connection is closed. The command's
+// streams are closed once the client goes away.
+func ExecPodWebSocket(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling ExecPodWebSocket", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	query := r.URL.Query()
+	cmd := query["cmd"]
+	if len(cmd) == 0 {
+		return 400, errors.New("cmd is a mandatory parameter")
+	}
+	withStdin, err := queryBool(r, "stdin", false)
+	if err != nil {
+		return 400, err
+	}
+	_, container, status, err := getRunningPod(namespace, podName, query.Get("container"))
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to find running container", logFields)
+
+		return status, err
+	}
+	logFields["container"], logFields["cmd"] = container, cmd
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		// The upgrader has replied to the client.
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to upgrade to WebSocket", logFields)
+
+		return http.StatusBadRequest, nil
+	}
+	defer conn.Close()
+
+	// The read loop ends when the client goes away.
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts := &execOptions{
+		ctx:       execCtx,
+		namespace: namespace,
+		podName:   podName,
+		container: container,
+		cmd:       cmd,
+		stdout:    conn.Writer(apitypes.StreamMessageStdout),
+		stderr:    conn.Writer(apitypes.StreamMessageStderr),
+	}
+	stdinReader, stdinWriter := io.Pipe()
+	if withStdin {
+		opts.stdin = stdinReader
+		go func() {
+			conn.ReadLoop(stdinWriter, nil)
+			cancel()
+		}()
+	} else {
+		go func() {
+			conn.ReadLoop(nil, nil)
+			cancel()
+		}()
+	}
+	exitCode, err := execInPod(opts)
+	// Unblock the read loop in case the command doesn't consume stdin.
+	stdinReader.CloseWithError(io.EOF)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to exec in Pod", logFields)
+		conn.WriteMessage(&v1types.StreamMessage{Type: apitypes.StreamMessageError, Data: err.Error()})
+
+		return status, nil
+	}
+	conn.WriteMessage(&v1types.StreamMessage{Type: apitypes.StreamMessageExit, ExitCode: &exitCode})
+
+	logFields["exitCode"] = exitCode
+	logger.InfoFields("Successfully exec in Pod over WebSocket", logFields)
+
+	return status, nil
+}
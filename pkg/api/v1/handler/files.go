@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+)
+
+const (
+	archiveFormatTar string = "tar"
+	archiveFormatZip string = "zip"
+
+	contentTypeTar string = "application/x-tar"
+	contentTypeZip string = "application/zip"
+)
+
+// queryFilePath returns the cleaned absolute path in query parameter path.
+func queryFilePath(r *http.Request) (string, error) {
+	value := r.URL.Query().Get("path")
+	if value == "" {
+		return "", errors.New("path is a mandatory parameter")
+	}
+	if !path.IsAbs(value) {
+		return "", fmt.Errorf("path %s should be absolute", value)
+	}
+	p := path.Clean(value)
+	if p == "/" {
+		return "", errors.New("path should not be the root directory")
+	}
+
+	return p, nil
+}
+
+// runInPod runs a short command in the container and returns error with stderr if it doesn't exit with 0.
+func runInPod(ctx context.Context, namespace, podName, container string, stdin io.Reader, cmd ...string) (int, error) {
+	stderr := &limitedBuffer{limit: maxExecOutput}
+	exitCode, err := execInPod(&execOptions{
+		ctx:       ctx,
+		namespace: namespace,
+		podName:   podName,
+		container: container,
+		cmd:       cmd,
+		stdin:     stdin,
+		stderr:    stderr,
+	})
+	if err != nil {
+		return exitCode, err
+	}
+	if exitCode != 0 {
+		return exitCode, fmt.Errorf("%s exits with %d: %s", cmd[0], exitCode, strings.TrimSpace(stderr.buf.String()))
+	}
+
+	return exitCode, nil
+}
+
+// DownloadPodFiles downloads the file or directory given by query parameter path out of the running worker. It's
+// streamed as tar archive, or zip archive if format=zip. Like kubectl cp, it runs tar inside the container so the
+// image must have tar.
+func DownloadPodFiles(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling DownloadPodFiles", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	filePath, err := queryFilePath(r)
+	if err != nil {
+		return 400, err
+	}
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = archiveFormatTar
+	}
+	if format != archiveFormatTar && format != archiveFormatZip {
+		return 400, fmt.Errorf("invalid format %s, expect %s or %s", format, archiveFormatTar, archiveFormatZip)
+	}
+	_, container, status, err := getRunningPod(namespace, podName, r.URL.Query().Get("container"))
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to find running container", logFields)
+
+		return status, err
+	}
+	logFields["container"], logFields["path"], logFields["format"] = container, filePath, format
+
+	// Check the path before streaming so the error can still be replied in status code.
+	if _, err = runInPod(ctx, namespace, podName, container, nil, "test", "-e", filePath); err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to find path in Pod", logFields)
+
+		return 404, fmt.Errorf("path %s is not found in %s", filePath, podName)
+	}
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	go func() {
+		dir, base := path.Split(filePath)
+		stderr := &limitedBuffer{limit: maxExecOutput}
+		exitCode, err := execInPod(&execOptions{
+			ctx:       ctx,
+			namespace: namespace,
+			podName:   podName,
+			container: container,
+			cmd:       []string{"tar", "cf", "-", "-C", dir, base},
+			stdout:    writer,
+			stderr:    stderr,
+		})
+		if err == nil && exitCode != 0 {
+			err = fmt.Errorf("tar exits with %d: %s", exitCode, strings.TrimSpace(stderr.buf.String()))
+		}
+		writer.CloseWithError(err)
+	}()
+
+	contentType := contentTypeTar
+	if format == archiveFormatZip {
+		contentType = contentTypeZip
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", path.Base(filePath)+"."+format))
+	w.WriteHeader(status)
+	if format == archiveFormatZip {
+		err = tarToZip(reader, w)
+	} else {
+		_, err = io.Copy(w, reader)
+	}
+	if err != nil && ctx.Err() == nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Download stream is interrupted", logFields)
+	}
+
+	logger.InfoFields("Successfully download files from Pod", logFields)
+
+	return status, nil
+}
+
+// tarToZip converts the tar stream into zip stream. Only regular files and directories are kept.
+func tarToZip(src io.Reader, dst io.Writer) error {
+	tr := tar.NewReader(src)
+	zw := zip.NewWriter(dst)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		info := hdr.FileInfo()
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			continue
+		}
+		zipHdr, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		zipHdr.Name = hdr.Name
+		if info.IsDir() {
+			zipHdr.Name = strings.TrimSuffix(hdr.Name, "/") + "/"
+		} else {
+			zipHdr.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(zipHdr)
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			if _, err = io.Copy(fw, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return zw.Close()
+}
+
+// countingReader counts the bytes read from the reader.
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+// UploadPodFiles uploads files into the running worker. If the request is a tar archive with Content-Type
+// application/x-tar, it's extracted into the directory given by query parameter path. Otherwise the body is
+// saved as the file path, which requires Content-Length. Like kubectl cp, it runs tar inside the container.
+func UploadPodFiles(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling UploadPodFiles", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	filePath, err := queryFilePath(r)
+	if err != nil {
+		return 400, err
+	}
+	isTar := strings.HasPrefix(r.Header.Get("Content-Type"), contentTypeTar)
+	if !isTar && r.ContentLength < 0 {
+		return http.StatusLengthRequired, errors.New("Content-Length is required to upload a single file")
+	}
+	_, container, status, err := getRunningPod(namespace, podName, r.URL.Query().Get("container"))
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to find running container", logFields)
+
+		return status, err
+	}
+	logFields["container"], logFields["path"], logFields["tar"] = container, filePath, isTar
+
+	body := &countingReader{reader: r.Body}
+	targetDir := filePath
+	var stdin io.Reader = body
+	if !isTar {
+		// Pack the single file into tar stream so it's extracted in the same way.
+		dir, base := path.Split(filePath)
+		targetDir = dir
+		reader, writer := io.Pipe()
+		defer reader.Close()
+		go func() {
+			tw := tar.NewWriter(writer)
+			err := tw.WriteHeader(&tar.Header{
+				Name:     base,
+				Mode:     0644,
+				Size:     r.ContentLength,
+				ModTime:  time.Now(),
+				Typeflag: tar.TypeReg,
+			})
+			if err == nil {
+				_, err = io.CopyN(tw, body, r.ContentLength)
+			}
+			if err == nil {
+				err = tw.Close()
+			}
+			writer.CloseWithError(err)
+		}()
+		stdin = reader
+	}
+
+	if _, err = runInPod(ctx, namespace, podName, container, nil, "mkdir", "-p", targetDir); err != nil {
+		errMsg := "Fail to create directory in Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return 400, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	if _, err = runInPod(ctx, namespace, podName, container, stdin, "tar", "xf", "-", "-C", targetDir); err != nil {
+		errMsg := "Fail to extract files in Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return 400, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logFields["bytes"] = body.count
+	if err = writeJSON(w, status, &v1types.UploadResult{Path: filePath, Bytes: body.count}); err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to write upload result", logFields)
+	}
+
+	logger.InfoFields("Successfully upload files into Pod", logFields)
+
+	return status, nil
+}
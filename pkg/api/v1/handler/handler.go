@@ -1,22 +1,37 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
 	"github.com/jinghzhu/kservice/pkg/api/v1/types"
 	"github.com/jinghzhu/kservice/pkg/config"
 	"github.com/jinghzhu/kservice/pkg/logger"
+	kpod "github.com/jinghzhu/kutils/pod"
 
 	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
 	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	// maxLogBytes is the max size of log returned in JSON.
+	maxLogBytes int64 = 10 << 20
+
+	deletionPollPeriod     time.Duration = time.Second
+	deletionCleanupTimeout time.Duration = 30 * time.Second
 )
 
 // CreatePod creates a Pod.
@@ -42,6 +57,15 @@ func CreatePod(ctx context.Context, r *http.Request) (result []byte, status int,
 
 		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
 	}
+	if err = resolveWorkerNamespace(r, wp); err != nil {
+		logger.ErrorFields("Namespace is not allowed", logger.Fields{
+			apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+			apitypes.LogWorkerNamespace: wp.Namespace,
+			logger.ERROR:                err,
+		})
+
+		return result, 403, err
+	}
 
 	// Translate to pod
 	podObj, err := adapter.TranslateWorkerPodToPod(ctx, wp)
@@ -54,6 +78,23 @@ func CreatePod(ctx context.Context, r *http.Request) (result []byte, status int,
 
 		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
 	}
+	if wp.RetryPolicy != nil {
+		if err = adapter.ApplyRetryPolicy(podObj, wp.RetryPolicy); err != nil {
+			errMsg := "Fail to set retry policy"
+			logger.ErrorFields(errMsg, logger.Fields{
+				apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+				logger.ERROR:      err,
+			})
+
+			return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+		}
+	}
+	if wp.RunAt != nil && wp.RunAt.After(time.Now()) {
+		return createDelayedPod(ctx, podObj, *wp.RunAt)
+	}
+	if config.GetConfig().IsQueueEnabled() {
+		return enqueuePod(ctx, podObj)
+	}
 
 	// Create Pod in Kubernetes.
 	pod, err := apitypes.DefaultPodClient().CreatePod(podObj, podObj.GetNamespace(), metav1.CreateOptions{})
@@ -106,10 +147,27 @@ func GetPodStatus(ctx context.Context, r *http.Request) (result []byte, status i
 		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
 		apitypes.LogWorkerName: podName,
 	})
-	g := config.GetConfig()
+	namespace, err := workerNamespace(r)
+	if err != nil {
+		logger.ErrorFields("Namespace is not allowed", logger.Fields{
+			apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+			apitypes.LogWorkerName:      podName,
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
 
-	// Get Pod from Kubernetes.
-	pod, err := apitypes.DefaultPodClient().GetPod(g.WorkerNamespace, podName, metav1.GetOptions{})
+		return result, 403, err
+	}
+
+	// Get Pod from Kubernetes. The worker waiting in admission queue has no Pod yet and the preempted one has no
+	// Pod any more.
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		if workerStatus := podlessWorkerStatus(ctx, namespace, podName); workerStatus != nil {
+			result, err = json.Marshal(workerStatus)
+			return result, status, err
+		}
+	}
 	if err != nil {
 		errMsg := "Fail to get Pod"
 		logger.ErrorFields(errMsg, logger.Fields{
@@ -121,25 +179,25 @@ func GetPodStatus(ctx context.Context, r *http.Request) (result []byte, status i
 		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
 	}
 
-	// Parse worker status.
-	workerStatus := &v1types.WorkerStatus{
-		Reason:  pod.Status.Reason,
-		Message: pod.Status.Message,
-		State:   apitypes.WorkerStatusUnknown,
-		Status:  string(pod.Status.Phase),
-	}
-	if pod.Status.ContainerStatuses != nil {
-		if pod.Status.ContainerStatuses[0].State.Waiting != nil {
-			workerStatus.Reason = pod.Status.ContainerStatuses[0].State.Waiting.Reason
-			workerStatus.Message = pod.Status.ContainerStatuses[0].State.Waiting.Message
-			workerStatus.State = apitypes.WorkerStatusWaiting
-		} else if pod.Status.ContainerStatuses[0].State.Running != nil {
-			workerStatus.State = apitypes.WorkerStatusRunning
-		} else if pod.Status.ContainerStatuses[0].State.Terminated != nil {
-			workerStatus.State = apitypes.WorkerStatusTerminated
-			workerStatus.Reason = pod.Status.ContainerStatuses[0].State.Terminated.Reason
-			workerStatus.Message = pod.Status.ContainerStatuses[0].State.Terminated.Message
-			workerStatus.ExitCode = &pod.Status.ContainerStatuses[0].State.Terminated.ExitCode
+	// Parse worker status. The worker with retry policy reports all its attempts.
+	workerStatus := adapter.TranslatePodStatus(pod)
+	if id, ok := pod.GetLabels()[apitypes.LabelRetryOf]; ok {
+		attempts, err := apitypes.DefaultPodClient().ListPods(namespace, metav1.ListOptions{
+			LabelSelector: apitypes.LabelRetryOf + "=" + id,
+		})
+		if err != nil {
+			errMsg := "Fail to list attempts"
+			logger.ErrorFields(errMsg, logger.Fields{
+				apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+				apitypes.LogWorkerName: podName,
+				logger.ERROR:           err,
+			})
+
+			return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+		}
+		adapter.SortAttempts(attempts.Items)
+		if retryStatus := adapter.TranslateRetryStatus(attempts.Items); retryStatus != nil {
+			workerStatus = retryStatus
 		}
 	}
 	result, err = json.Marshal(workerStatus)
@@ -170,7 +228,28 @@ func GetPodStatus(ctx context.Context, r *http.Request) (result []byte, status i
 	return result, status, err
 }
 
-// GetPodLog retrieves Pod logs.
+// podlessWorkerStatus returns the status of worker which is queued or preempted, or nil if there's no such worker.
+func podlessWorkerStatus(ctx context.Context, namespace, id string) *v1types.WorkerStatus {
+	cm, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	switch {
+	case cm.GetLabels()[apitypes.LabelQueuedWorker] == id:
+		return adapter.TranslateQueuedStatus(cm)
+	case cm.GetLabels()[apitypes.LabelPreemptedWorker] == id:
+		workerStatus, err := adapter.TranslatePreemptedStatus(cm)
+		if err != nil {
+			return nil
+		}
+		return workerStatus
+	}
+
+	return nil
+}
+
+// GetPodLog retrieves Pod logs in JSON. The log is capped by limitBytes, which is maxLogBytes at most, and the
+// truncated log is flagged in the response.
 func GetPodLog(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
 	status = http.StatusOK
 	vars := mux.Vars(r)
@@ -180,11 +259,27 @@ func GetPodLog(ctx context.Context, r *http.Request) (result []byte, status int,
 		apitypes.LogWorkerName: podName,
 	}
 	logger.InfoFields("Calling getPodLog", logFields)
-	g := config.GetConfig()
-	logFields[apitypes.LogWorkerNamespace] = g.WorkerNamespace
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	opts, err := parseLogOptions(r)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse log options", logFields)
+
+		return result, 400, err
+	}
+	// Following logs and logs in plain text are served by StreamPodLog.
+	opts.Follow = false
+	limit := limitLogBytes(opts)
 
 	// Get logs.
-	podLog, err := apitypes.DefaultPodClient().GetLogString(g.WorkerNamespace, podName, &corev1.PodLogOptions{})
+	podLog, err := apitypes.DefaultPodClient().GetLogString(namespace, podName, opts)
 	if err != nil {
 		errMsg := "Fail to find log stream"
 		logFields[logger.ERROR] = err
@@ -194,9 +289,8 @@ func GetPodLog(ctx context.Context, r *http.Request) (result []byte, status int,
 	}
 
 	// Parser logs into responses.
-	logs := &types.Logs{
-		Log: podLog,
-	}
+	logs := &types.Logs{}
+	logs.Log, logs.Truncated = truncateLog(podLog, limit)
 	result, err = json.Marshal(logs)
 	if err != nil {
 		errMsg := "Fail to marshal logs into JSON"
@@ -211,6 +305,88 @@ func GetPodLog(ctx context.Context, r *http.Request) (result []byte, status int,
 	return result, status, err
 }
 
+// StreamPodLog streams Pod logs to the client in chunked plain text, or in Server-Sent Events if the client accepts
+// text/event-stream. If follow is true, the stream ends when the container stops or the client disconnects.
+func StreamPodLog(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling StreamPodLog", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	opts, err := parseLogOptions(r)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse log options", logFields)
+
+		return 400, err
+	}
+
+	// Open log stream.
+	stream, err := apitypes.DefaultKubeClient().CoreV1().Pods(namespace).GetLogs(podName, opts).Stream(ctx)
+	if err != nil {
+		errMsg := "Fail to find log stream"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsBadRequest(err) {
+			status = 400
+		} else {
+			status = 404
+		}
+
+		return status, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	defer stream.Close()
+
+	if wantSSE(r) {
+		var sse *sseWriter
+		if sse, err = newSSEWriter(w); err != nil {
+			return 500, err
+		}
+		reader := bufio.NewReader(stream)
+		for {
+			line, readErr := reader.ReadString('\n')
+			if line != "" {
+				if err = sse.WriteEvent("", "", strings.TrimRight(line, "\r\n")); err != nil {
+					break
+				}
+			}
+			if readErr != nil {
+				if readErr == io.EOF {
+					err = sse.WriteEvent("", sseEventEnd, "")
+				} else {
+					err = readErr
+				}
+				break
+			}
+		}
+	} else {
+		var fw *flushWriter
+		if fw, err = newFlushWriter(w, contentTypeText); err != nil {
+			return 500, err
+		}
+		_, err = io.Copy(fw, stream)
+	}
+	if err != nil && ctx.Err() == nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Log stream is interrupted", logFields)
+	}
+
+	logger.InfoFields("Successfully stream Pod logs", logFields)
+
+	return status, nil
+}
+
 // GetPodInfo gets pod spec.
 func GetPodInfo(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
 	status = http.StatusOK
@@ -220,10 +396,20 @@ func GetPodInfo(ctx context.Context, r *http.Request) (result []byte, status int
 		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
 		apitypes.LogWorkerName: podName,
 	})
-	g := config.GetConfig()
+	namespace, err := workerNamespace(r)
+	if err != nil {
+		logger.ErrorFields("Namespace is not allowed", logger.Fields{
+			apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+			apitypes.LogWorkerName:      podName,
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+
+		return result, 403, err
+	}
 
 	// Get Pod from Kubernetes.
-	pod, err := apitypes.DefaultPodClient().GetPod(g.WorkerNamespace, podName, metav1.GetOptions{})
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
 	if err != nil {
 		errMsg := "Fail to get Pod"
 		logger.ErrorFields(errMsg, logger.Fields{
@@ -264,3 +450,279 @@ func GetPodInfo(ctx context.Context, r *http.Request) (result []byte, status int
 
 	return result, status, err
 }
+
+// DeletePod cancels the worker by deleting its Pod. If the query parameter wait is true, it'll block until the Pod
+// is removed from Kubernetes or its grace period expires, and report the worker status observed at last.
+func DeletePod(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling DeletePod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	gracePeriod, err := queryInt64(r, "gracePeriod")
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse grace period", logFields)
+
+		return result, 400, err
+	}
+	if gracePeriod == nil {
+		// Negative value makes the Pod client to use its default grace period.
+		defaultPeriod := int64(-1)
+		gracePeriod = &defaultPeriod
+	} else if *gracePeriod < 0 {
+		return result, 400, fmt.Errorf("grace period of %s should not be negative", podName)
+	}
+	wait, err := queryBool(r, "wait", false)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse wait flag", logFields)
+
+		return result, 400, err
+	}
+	logFields["gracePeriod"], logFields["wait"] = *gracePeriod, wait
+
+	// Get Pod from Kubernetes to record its last status.
+	podClient := apitypes.DefaultPodClient()
+	pod, err := podClient.GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	workerStatus := adapter.TranslatePodStatus(pod)
+
+	// Delete Pod in Kubernetes. Waiting for the deletion mustn't force to delete the Pod, so that the worker can
+	// still terminate gracefully.
+	opts := metav1.DeleteOptions{GracePeriodSeconds: gracePeriod}
+	if wait {
+		if *gracePeriod < 0 {
+			opts.GracePeriodSeconds = nil
+		}
+		err = podClient.DeletePod(namespace, podName, opts)
+	} else {
+		err = podClient.DeletePodWithCheck(namespace, podName, opts)
+	}
+	if err != nil {
+		errMsg := "Fail to delete Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	// Delete the other attempts of worker with retry policy, so that it won't be retried any more.
+	if id, ok := pod.GetLabels()[apitypes.LabelRetryOf]; ok {
+		collectionOpts := metav1.DeleteOptions{}
+		if *gracePeriod >= 0 {
+			collectionOpts.GracePeriodSeconds = gracePeriod
+		}
+		podsClient := apitypes.DefaultKubeClient().CoreV1().Pods(namespace)
+		err = podsClient.DeleteCollection(ctx, collectionOpts, metav1.ListOptions{
+			LabelSelector: apitypes.LabelRetryOf + "=" + id,
+		})
+		if err != nil {
+			errMsg := "Fail to delete attempts"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+		}
+	}
+
+	if wait {
+		var deleted bool
+		workerStatus, deleted = waitForPodDeletion(ctx, pod, deletionTimeout(pod, opts.GracePeriodSeconds))
+		logFields["deleted"] = deleted
+	}
+	workerStatus.Reason = apitypes.WorkerStatusReasonCancelled
+	workerStatus.Message = "Worker is cancelled by user request"
+	result, err = json.Marshal(workerStatus)
+	if err != nil {
+		errMsg := "Fail to marshal Pod status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logger.InfoFields("Successfully delete Pod", logFields)
+
+	return result, status, err
+}
+
+// deletionTimeout returns how long to wait for the Pod to be removed, which is its grace period plus some time for
+// Kubernetes to clean it up.
+func deletionTimeout(pod *corev1.Pod, gracePeriod *int64) time.Duration {
+	period := int64(corev1.DefaultTerminationGracePeriodSeconds)
+	if gracePeriod != nil {
+		period = *gracePeriod
+	} else if pod.Spec.TerminationGracePeriodSeconds != nil {
+		period = *pod.Spec.TerminationGracePeriodSeconds
+	}
+
+	return time.Duration(period)*time.Second + deletionCleanupTimeout
+}
+
+// waitForPodDeletion polls the Pod until it's removed from Kubernetes or the timeout expires. It returns the last
+// observed worker status and whether the Pod is removed. The Pod recreated with the same name is treated as removed.
+func waitForPodDeletion(ctx context.Context, pod *corev1.Pod, timeout time.Duration) (*v1types.WorkerStatus, bool) {
+	workerStatus := adapter.TranslatePodStatus(pod)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(deletionPollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-waitCtx.Done():
+			return workerStatus, false
+		case <-ticker.C:
+		}
+		current, err := apitypes.DefaultPodClient().GetPod(pod.GetNamespace(), pod.GetName(), metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) || (err == nil && current.GetUID() != pod.GetUID()) {
+			workerStatus.State = apitypes.WorkerStatusTerminated
+			return workerStatus, true
+		}
+		if err == nil {
+			workerStatus = adapter.TranslatePodStatus(current)
+		}
+	}
+}
+
+// workerFilter is the condition to filter workers which can't be done by Kubernetes selectors.
+type workerFilter struct {
+	state         string
+	image         string
+	createdAfter  *time.Time
+	createdBefore *time.Time
+}
+
+func (f *workerFilter) match(pod *corev1.Pod, workerStatus *v1types.WorkerStatus) bool {
+	if f.state != "" && !strings.EqualFold(f.state, workerStatus.State) {
+		return false
+	}
+	if f.image != "" {
+		found := false
+		for _, image := range kpod.GetPodImages(pod) {
+			if image == f.image || strings.HasPrefix(image, f.image+":") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	created := pod.GetCreationTimestamp().Time
+	if f.createdAfter != nil && created.Before(*f.createdAfter) {
+		return false
+	}
+	if f.createdBefore != nil && !created.Before(*f.createdBefore) {
+		return false
+	}
+
+	return true
+}
+
+// ListPods lists the worker Pods created by kservice page by page. The label selector, phase and pagination are
+// handled by Kubernetes while the state, image and creation time filters are applied on each page, so a page may
+// contain fewer items than the limit even if it isn't the last one.
+func ListPods(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	query := r.URL.Query()
+	namespace, err := workerNamespace(r)
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: namespace,
+		"query":                     query,
+	}
+	logger.InfoFields("Calling ListPods", logFields)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	opts := metav1.ListOptions{
+		LabelSelector: adapter.ManagedWorkerSelector(query.Get("labelSelector")),
+		Continue:      query.Get("continue"),
+	}
+	limit, err := queryInt64(r, "limit")
+	if err != nil || (limit != nil && *limit <= 0) {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse limit", logFields)
+
+		return result, 400, fmt.Errorf("limit should be a positive integer")
+	}
+	if limit != nil {
+		opts.Limit = *limit
+	}
+	if phase := query.Get("phase"); phase != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("status.phase", phase).String()
+	}
+	filter := &workerFilter{
+		state: query.Get("state"),
+		image: query.Get("image"),
+	}
+	if filter.createdAfter, err = queryTime(r, "createdAfter"); err != nil {
+		return result, 400, err
+	}
+	if filter.createdBefore, err = queryTime(r, "createdBefore"); err != nil {
+		return result, 400, err
+	}
+
+	// List Pods from Kubernetes.
+	pods, err := apitypes.DefaultPodClient().ListPods(namespace, opts)
+	if err != nil {
+		errMsg := "Fail to list Pods"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsBadRequest(err) || k8serrors.IsResourceExpired(err) {
+			status = 400
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	workerList := &v1types.WorkerList{
+		Items:    []v1types.WorkerSummary{},
+		Continue: pods.GetContinue(),
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		summary := adapter.TranslatePodSummary(pod)
+		if !filter.match(pod, &summary.WorkerStatus) {
+			continue
+		}
+		workerList.Items = append(workerList.Items, summary)
+	}
+	result, err = json.Marshal(workerList)
+	if err != nil {
+		errMsg := "Fail to marshal worker list into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	logFields["count"] = len(workerList.Items)
+	logger.InfoFields("Successfully list Pods", logFields)
+
+	return result, status, err
+}
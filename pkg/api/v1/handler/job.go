@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// listJobPods returns the Pods created by the Job.
+func listJobPods(job *batchv1.Job) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := apitypes.DefaultPodClient().ListPods(job.GetNamespace(), metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pods.Items, nil
+}
+
+// CreateJob creates a Job which runs the worker with retry and deadline. The Job is held in admission queue if
+// it's enabled.
+func CreateJob(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create Job", logFields)
+	if r.Body == nil {
+		errMsg := "No POST parameters found in the request"
+		logger.Error(errMsg)
+
+		return result, 400, errors.New(errMsg)
+	}
+	// Set Job spec from input parameters.
+	wj, err := adapter.InitWorkerJob(ctx, r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if err = resolveWorkerNamespace(r, &wj.WorkerPod); err != nil {
+		logFields[apitypes.LogWorkerNamespace] = wj.Namespace
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	// Translate to Job.
+	jobObj, err := adapter.TranslateWorkerJobToJob(ctx, wj)
+	if err != nil {
+		errMsg := "Fail to init Job object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	if config.GetConfig().IsQueueEnabled() {
+		return enqueueJob(ctx, jobObj)
+	}
+
+	// Create Job in Kubernetes.
+	job, err := apitypes.DefaultKubeClient().BatchV1().Jobs(jobObj.GetNamespace()).Create(ctx, jobObj, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create Job"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	jobName := job.GetName()
+	logFields[apitypes.LogWorkerName], logFields[apitypes.LogWorkerNamespace] = jobName, job.GetNamespace()
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: jobName})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+
+	logger.InfoFields("Successfully create Job", logFields)
+
+	return result, status, err
+}
+
+// queuedJobStatus returns the status of Job waiting in admission queue, or nil if there's no such Job.
+func queuedJobStatus(ctx context.Context, namespace, jobName string) *v1types.JobStatus {
+	cm, err := getQueuedConfigMap(ctx, namespace, jobName)
+	if err != nil {
+		return nil
+	}
+	if job, err := adapter.QueuedJob(cm); err != nil || job == nil {
+		return nil
+	}
+
+	return adapter.TranslateQueuedJobStatus(cm)
+}
+
+// GetJobStatus retrieves the Job status together with the status of its workers.
+func GetJobStatus(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	jobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: jobName,
+	}
+	logger.InfoFields("Calling GetJobStatus", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	job, err := apitypes.DefaultKubeClient().BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		// The Job may be waiting in admission queue.
+		if jobStatus := queuedJobStatus(ctx, namespace, jobName); jobStatus != nil {
+			result, err = json.Marshal(jobStatus)
+			if err != nil {
+				errMsg := "Fail to marshal Job status into JSON"
+				logFields[logger.ERROR] = err
+				logger.ErrorFields(errMsg, logFields)
+
+				return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+			}
+			logger.InfoFields("Successfully get queued Job status", logFields)
+
+			return result, status, err
+		}
+	}
+	if err != nil {
+		errMsg := "Fail to get Job"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+	pods, err := listJobPods(job)
+	if err != nil {
+		errMsg := "Fail to list Job Pods"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+	result, err = json.Marshal(adapter.TranslateJobStatus(job, pods))
+	if err != nil {
+		errMsg := "Fail to marshal Job status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+
+	logger.InfoFields("Successfully get Job status", logFields)
+
+	return result, status, err
+}
+
+// GetJobLog retrieves the logs of all workers of the Job. The log options are the same as GetPodLog except
+// follow, and each log is capped in the same way. The logs are capped by maxLogBytes in total as well, so the log
+// isn't read once the cap is reached and is flagged as truncated. A worker whose log can't be retrieved has the
+// error in its entry.
+func GetJobLog(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	jobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: jobName,
+	}
+	logger.InfoFields("Calling GetJobLog", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	opts, err := parseLogOptions(r)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse log options", logFields)
+
+		return result, 400, err
+	}
+	opts.Follow = false
+	limit := limitLogBytes(opts)
+
+	job, err := apitypes.DefaultKubeClient().BatchV1().Jobs(namespace).Get(ctx, jobName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Job"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+	pods, err := listJobPods(job)
+	if err != nil {
+		errMsg := "Fail to list Job Pods"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+
+	jobLogs := &v1types.JobLogs{
+		Id:   jobName,
+		Logs: []v1types.WorkerLog{},
+	}
+	// The logs of all workers are capped by maxLogBytes in total too.
+	remaining := maxLogBytes
+	for _, summary := range adapter.TranslateJobStatus(job, pods).Workers {
+		workerLog := v1types.WorkerLog{Id: summary.Id}
+		podLimit := limit
+		if podLimit > remaining {
+			podLimit = remaining
+		}
+		if podLimit == 0 {
+			workerLog.Truncated = true
+			jobLogs.Logs = append(jobLogs.Logs, workerLog)
+			continue
+		}
+		podOpts := *opts
+		readBytes := podLimit + 1
+		podOpts.LimitBytes = &readBytes
+		podLog, err := apitypes.DefaultPodClient().GetLogString(namespace, summary.Id, &podOpts)
+		if err != nil {
+			workerLog.Error = err.Error()
+		} else {
+			workerLog.Log, workerLog.Truncated = truncateLog(podLog, podLimit)
+			remaining -= int64(len(workerLog.Log))
+		}
+		jobLogs.Logs = append(jobLogs.Logs, workerLog)
+	}
+	result, err = json.Marshal(jobLogs)
+	if err != nil {
+		errMsg := "Fail to marshal logs into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+
+	logger.InfoFields("Successfully get Job logs", logFields)
+
+	return result, status, err
+}
+
+// DeleteJob deletes the Job and its workers.
+func DeleteJob(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	jobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: jobName,
+	}
+	logger.InfoFields("Calling DeleteJob", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err = apitypes.DefaultKubeClient().BatchV1().Jobs(namespace).Delete(ctx, jobName, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	// The Job waiting in admission queue is dropped from it.
+	if k8serrors.IsNotFound(err) && queuedJobStatus(ctx, namespace, jobName) != nil {
+		err = dropQueuedPod(ctx, namespace, jobName)
+	}
+	if err != nil {
+		errMsg := "Fail to delete Job"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: jobName})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, jobName, err)
+	}
+
+	logger.InfoFields("Successfully delete Job", logFields)
+
+	return result, status, err
+}
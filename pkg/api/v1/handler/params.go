@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/config"
+
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// queryBool parses the bool query parameter. It returns the default value if the parameter is not set.
+func queryBool(r *http.Request, key string, defaultValue bool) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("invalid value %q of query parameter %s", value, key)
+	}
+
+	return b, nil
+}
+
+// queryInt64 parses the int64 query parameter. It returns nil if the parameter is not set.
+func queryInt64(r *http.Request, key string) (*int64, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q of query parameter %s", value, key)
+	}
+
+	return &i, nil
+}
+
+// queryTime parses the RFC3339 time query parameter. It returns nil if the parameter is not set.
+func queryTime(r *http.Request, key string) (*time.Time, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q of query parameter %s, expect RFC3339 format", value, key)
+	}
+
+	return &t, nil
+}
+
+// parseLogOptions builds the Pod log options from query parameters follow, tailLines, sinceSeconds, sinceTime,
+// timestamps, previous and container.
+func parseLogOptions(r *http.Request) (*corev1.PodLogOptions, error) {
+	opts := &corev1.PodLogOptions{
+		Container: r.URL.Query().Get("container"),
+	}
+	var err error
+	if opts.Follow, err = queryBool(r, "follow", false); err != nil {
+		return opts, err
+	}
+	if opts.Timestamps, err = queryBool(r, "timestamps", false); err != nil {
+		return opts, err
+	}
+	if opts.Previous, err = queryBool(r, "previous", false); err != nil {
+		return opts, err
+	}
+	if opts.TailLines, err = queryInt64(r, "tailLines"); err != nil {
+		return opts, err
+	}
+	if opts.TailLines != nil && *opts.TailLines < 0 {
+		return opts, fmt.Errorf("tailLines should not be negative")
+	}
+	if opts.SinceSeconds, err = queryInt64(r, "sinceSeconds"); err != nil {
+		return opts, err
+	}
+	if opts.SinceSeconds != nil && *opts.SinceSeconds <= 0 {
+		return opts, fmt.Errorf("sinceSeconds should be a positive integer")
+	}
+	if opts.LimitBytes, err = queryInt64(r, "limitBytes"); err != nil {
+		return opts, err
+	}
+	if opts.LimitBytes != nil && *opts.LimitBytes <= 0 {
+		return opts, fmt.Errorf("limitBytes should be a positive integer")
+	}
+	sinceTime, err := queryTime(r, "sinceTime")
+	if err != nil {
+		return opts, err
+	}
+	if sinceTime != nil {
+		if opts.SinceSeconds != nil {
+			return opts, fmt.Errorf("sinceSeconds and sinceTime can't be set at the same time")
+		}
+		t := metav1.NewTime(*sinceTime)
+		opts.SinceTime = &t
+	}
+
+	return opts, nil
+}
+
+// limitLogBytes caps the log returned in JSON by maxLogBytes, so the whole log of a chatty worker isn't read into
+// memory. The longer log can be streamed in plain text. One more byte than the returned limit is requested, so that
+// truncateLog can tell whether the log is truncated.
+func limitLogBytes(opts *corev1.PodLogOptions) int64 {
+	limit := maxLogBytes
+	if opts.LimitBytes != nil && *opts.LimitBytes < limit {
+		limit = *opts.LimitBytes
+	}
+	readBytes := limit + 1
+	opts.LimitBytes = &readBytes
+
+	return limit
+}
+
+// truncateLog cuts the log read with the options of limitLogBytes at limit and returns true if it's truncated.
+func truncateLog(log string, limit int64) (string, bool) {
+	if int64(len(log)) > limit {
+		return log[:limit], true
+	}
+
+	return log, false
+}
+
+// queryDuration parses the duration query parameter such as 90s or 5m. A plain integer is taken as seconds.
+func queryDuration(r *http.Request, key string, defaultValue time.Duration) (time.Duration, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue, fmt.Errorf("invalid value %q of query parameter %s", value, key)
+	}
+
+	return d, nil
+}
+
+// workerNamespace returns the namespace in request path, or the default worker namespace if it's not set. It
+// returns error if the namespace isn't allowed.
+func workerNamespace(r *http.Request) (string, error) {
+	g := config.GetConfig()
+	namespace := mux.Vars(r)["ns"]
+	if namespace == "" {
+		namespace = g.WorkerNamespace
+	}
+	if !g.IsNamespaceAllowed(namespace) {
+		return namespace, fmt.Errorf("namespace %s is not allowed", namespace)
+	}
+
+	return namespace, nil
+}
+
+// resolveWorkerNamespace sets the namespace of WorkerPod to be created. The namespace in request path takes
+// precedence over the one in POST params. It returns error if the namespace isn't allowed.
+func resolveWorkerNamespace(r *http.Request, wp *v1types.WorkerPod) error {
+	g := config.GetConfig()
+	if ns := mux.Vars(r)["ns"]; ns != "" {
+		wp.Namespace = ns
+	} else if wp.Namespace == "" {
+		wp.Namespace = g.WorkerNamespace
+	}
+	if !g.IsNamespaceAllowed(wp.Namespace) {
+		return fmt.Errorf("namespace %s is not allowed", wp.Namespace)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+)
+
+var (
+	// proxyPortPattern matches the port number or the port name of container.
+	proxyPortPattern = regexp.MustCompile(`^([0-9]{1,5}|[a-z0-9]([a-z0-9-]{0,13}[a-z0-9])?)$`)
+
+	proxyTransport     http.RoundTripper
+	proxyTransportErr  error
+	proxyTransportOnce sync.Once
+
+	upgradeTransport     http.RoundTripper
+	upgradeTransportErr  error
+	upgradeTransportOnce sync.Once
+)
+
+// getProxyTransport returns the transport which talks to Kubernetes API server with the default credential. The
+// upgrade request such as WebSocket is sent with the transport which only speaks HTTP/1.1, because the connection
+// can't be upgraded over HTTP/2 negotiated by the default one.
+func getProxyTransport(upgrade bool) (http.RoundTripper, error) {
+	if upgrade {
+		upgradeTransportOnce.Do(func() {
+			restConfig := rest.CopyConfig(apitypes.DefaultRestConfig())
+			restConfig.NextProtos = []string{"http/1.1"}
+			upgradeTransport, upgradeTransportErr = rest.TransportFor(restConfig)
+		})
+
+		return upgradeTransport, upgradeTransportErr
+	}
+	proxyTransportOnce.Do(func() {
+		proxyTransport, proxyTransportErr = rest.TransportFor(apitypes.DefaultRestConfig())
+	})
+
+	return proxyTransport, proxyTransportErr
+}
+
+// ProxyPod reverse-proxies HTTP requests, including WebSocket upgrades, to the port inside the worker through the
+// Pod proxy subresource of Kubernetes. The port is the path variable port and the rest of path is passed to the
+// worker with the query string.
+func ProxyPod(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName, port := vars["key"], vars["port"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+		"port":                 port,
+		"path":                 vars["path"],
+	}
+	logger.InfoFields("Calling ProxyPod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	if !proxyPortPattern.MatchString(port) {
+		return 400, fmt.Errorf("invalid port %s", port)
+	}
+
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return 409, fmt.Errorf("worker %s is %s", podName, pod.Status.Phase)
+	}
+	transport, err := getProxyTransport(httpstream.IsUpgradeRequest(r))
+	if err != nil {
+		errMsg := "Fail to init proxy transport"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	target := apitypes.DefaultKubeClient().CoreV1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("pods").
+		Name(podName + ":" + port).
+		SubResource("proxy").
+		Suffix(vars["path"]).
+		URL()
+	if strings.HasSuffix(r.URL.Path, "/") && !strings.HasSuffix(target.Path, "/") {
+		target.Path += "/"
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme, req.URL.Host, req.URL.Path = target.Scheme, target.Host, target.Path
+			req.URL.RawPath = ""
+			req.Host = target.Host
+			// The credential of client is meant for kservice. The transport sets the one of kservice.
+			req.Header.Del("Authorization")
+		},
+		Transport:     transport,
+		FlushInterval: -1,
+		ErrorHandler: func(rw http.ResponseWriter, req *http.Request, err error) {
+			logFields[logger.ERROR] = err
+			logger.ErrorFields("Fail to proxy request to Pod", logFields)
+			rw.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r.WithContext(ctx))
+
+	logger.InfoFields("Successfully proxy request to Pod", logFields)
+
+	return status, nil
+}
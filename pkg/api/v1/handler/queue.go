@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// enqueuePod holds the Pod in admission queue which is created by the queue controller when the limits of active
+// workers allow. The ID in result is the name of the Pod to be created.
+func enqueuePod(ctx context.Context, podObj *corev1.Pod) (result []byte, status int, err error) {
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: podObj.GetNamespace(),
+	}
+	cm, err := adapter.TranslatePodToQueuedConfigMap(podObj)
+	if err != nil {
+		errMsg := "Fail to init queued worker object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	return createQueuedConfigMap(ctx, cm, logFields)
+}
+
+// enqueueJob holds the Job in admission queue in the same way as enqueuePod. The ID in result is the name of the
+// Job to be created.
+func enqueueJob(ctx context.Context, jobObj *batchv1.Job) (result []byte, status int, err error) {
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: jobObj.GetNamespace(),
+	}
+	cm, err := adapter.TranslateJobToQueuedConfigMap(jobObj)
+	if err != nil {
+		errMsg := "Fail to init queued Job object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	return createQueuedConfigMap(ctx, cm, logFields)
+}
+
+// createQueuedConfigMap creates the ConfigMap of queued worker and returns its ID.
+func createQueuedConfigMap(ctx context.Context, cm *corev1.ConfigMap,
+	logFields logger.Fields) (result []byte, status int, err error) {
+	logFields[apitypes.LogWorkerName] = cm.GetName()
+	_, err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(cm.GetNamespace()).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to queue worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: cm.GetName()})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cm.GetName(), err)
+	}
+
+	logger.InfoFields("Successfully queue worker", logFields)
+
+	return result, http.StatusAccepted, err
+}
+
+// listQueue lists the queued workers of all allowed namespaces in dispatch order.
+func listQueue(ctx context.Context) ([]corev1.ConfigMap, error) {
+	queue := []corev1.ConfigMap{}
+	for _, ns := range config.GetConfig().AllowedNamespaces {
+		cms, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: apitypes.LabelQueuedWorker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, cms.Items...)
+	}
+	adapter.SortQueue(queue)
+
+	return queue, nil
+}
+
+// findQueuedWorker returns the index of queued worker in queue or -1 if it's not found.
+func findQueuedWorker(queue []corev1.ConfigMap, namespace, id string) int {
+	for i := range queue {
+		if queue[i].GetNamespace() == namespace && queue[i].GetName() == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// getQueuedConfigMap gets the ConfigMap of queued worker.
+func getQueuedConfigMap(ctx context.Context, namespace, id string) (*corev1.ConfigMap, error) {
+	cm, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Get(ctx, id, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if cm.GetLabels()[apitypes.LabelQueuedWorker] != id {
+		return nil, fmt.Errorf("%s is not a queued worker", id)
+	}
+
+	return cm, nil
+}
+
+// dropQueuedPod removes the worker from admission queue if it's still there.
+func dropQueuedPod(ctx context.Context, namespace, id string) error {
+	cm, err := getQueuedConfigMap(ctx, namespace, id)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resourceVersion := cm.GetResourceVersion()
+	err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Delete(ctx, id, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// ListQueue lists the queued workers in dispatch order. The positions are across all allowed namespaces even if
+// only the workers of one namespace are listed.
+func ListQueue(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	namespace := mux.Vars(r)["ns"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: namespace,
+	}
+	logger.InfoFields("Calling ListQueue", logFields)
+	if namespace != "" && !config.GetConfig().IsNamespaceAllowed(namespace) {
+		err = fmt.Errorf("namespace %s is not allowed", namespace)
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	queue, err := listQueue(ctx)
+	if err != nil {
+		errMsg := "Fail to list queued workers"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	queueList := &v1types.QueuedWorkerList{Items: []v1types.QueuedWorker{}}
+	for _, qw := range adapter.TranslateQueue(queue) {
+		if namespace == "" || qw.Namespace == namespace {
+			queueList.Items = append(queueList.Items, qw)
+		}
+	}
+	result, err = json.Marshal(queueList)
+	if err != nil {
+		errMsg := "Fail to marshal queued worker list into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	logger.InfoFields("Successfully list queued workers", logFields)
+
+	return result, status, err
+}
+
+// GetQueuedWorker retrieves the queued worker with its position. It's not found after its Pod is created.
+func GetQueuedWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	id := mux.Vars(r)["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: id,
+	}
+	logger.InfoFields("Calling GetQueuedWorker", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	queue, err := listQueue(ctx)
+	if err != nil {
+		errMsg := "Fail to list queued workers"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	var queuedWorker *v1types.QueuedWorker
+	for _, qw := range adapter.TranslateQueue(queue) {
+		if qw.Namespace == namespace && qw.Id == id {
+			queuedWorker = &qw
+			break
+		}
+	}
+	if queuedWorker == nil {
+		errMsg := "Fail to get queued worker"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because it's not in queue", errMsg, id)
+	}
+	result, err = json.Marshal(queuedWorker)
+	if err != nil {
+		errMsg := "Fail to marshal queued worker into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+
+	logger.InfoFields("Successfully get queued worker", logFields)
+
+	return result, status, err
+}
+
+// ReorderQueuedWorker changes the priority of queued worker or moves it to the front or back of the queued workers
+// with the same priority.
+func ReorderQueuedWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	id := mux.Vars(r)["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: id,
+	}
+	logger.InfoFields("Calling ReorderQueuedWorker", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	if r.Body == nil {
+		return result, 400, fmt.Errorf("no PUT parameters found in the request")
+	}
+	update := &v1types.QueueUpdate{}
+	if err = json.NewDecoder(r.Body).Decode(update); err != nil {
+		errMsg := "Fail to parse JSON PUT params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	queue, err := listQueue(ctx)
+	if err != nil {
+		errMsg := "Fail to list queued workers"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	i := findQueuedWorker(queue, namespace, id)
+	if i < 0 {
+		errMsg := "Fail to get queued worker"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because it's not in queue", errMsg, id)
+	}
+	cm := queue[i].DeepCopy()
+	if adapter.IsQueuedWorkerClaimed(cm, time.Now()) {
+		errMsg := "Queued worker is being created"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 409, fmt.Errorf("%s for %s", errMsg, id)
+	}
+	if err = adapter.ReorderQueuedWorker(cm, update, queue); err != nil {
+		errMsg := "Invalid queue update"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	// The update fails with conflict if the worker is dispatched or reordered meanwhile.
+	cm, err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil {
+		errMsg := "Fail to reorder queued worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+			status = 409
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	queue[i] = *cm
+	adapter.SortQueue(queue)
+	var queuedWorker v1types.QueuedWorker
+	for _, qw := range adapter.TranslateQueue(queue) {
+		if qw.Namespace == namespace && qw.Id == id {
+			queuedWorker = qw
+			break
+		}
+	}
+	result, err = json.Marshal(&queuedWorker)
+	if err != nil {
+		errMsg := "Fail to marshal queued worker into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+
+	logger.InfoFields("Successfully reorder queued worker", logFields)
+
+	return result, status, err
+}
+
+// DropQueuedWorker removes the worker from admission queue before its Pod is created.
+func DropQueuedWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	id := mux.Vars(r)["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: id,
+	}
+	logger.InfoFields("Calling DropQueuedWorker", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cm, err := getQueuedConfigMap(ctx, namespace, id)
+	if err != nil {
+		errMsg := "Fail to get queued worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	if adapter.IsQueuedWorkerClaimed(cm, time.Now()) {
+		errMsg := "Queued worker is being created"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 409, fmt.Errorf("%s for %s", errMsg, id)
+	}
+	// The precondition makes sure the worker isn't claimed after it's read.
+	resourceVersion := cm.GetResourceVersion()
+	err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Delete(ctx, id, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if err != nil {
+		errMsg := "Fail to drop queued worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+			status = 409
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: id})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, id, err)
+	}
+
+	logger.InfoFields("Successfully drop queued worker", logFields)
+
+	return result, status, err
+}
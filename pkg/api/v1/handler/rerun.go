@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RerunPod creates a new worker with the same spec as the existing one. The image version, env and resources can
+// be overridden by the JSON body. The new worker is annotated with the worker it reruns and the original worker.
+func RerunPod(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	podName := mux.Vars(r)["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling RerunPod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	rerun, err := adapter.InitWorkerRerun(r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	podClient := apitypes.DefaultPodClient()
+	pod, err := podClient.GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+	if len(pod.Spec.Containers) == 0 {
+		return result, 400, fmt.Errorf("%s has no container to rerun", podName)
+	}
+	wp := adapter.TranslatePodToRerunWorkerPod(ctx, pod, rerun)
+	podObj, err := adapter.TranslateWorkerPodToPod(ctx, wp)
+	if err == nil && wp.RetryPolicy != nil {
+		err = adapter.ApplyRetryPolicy(podObj, wp.RetryPolicy)
+	}
+	if err != nil {
+		errMsg := "Fail to init Pod object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if config.GetConfig().IsQueueEnabled() {
+		return enqueuePod(ctx, podObj)
+	}
+
+	newPod, err := podClient.CreatePod(podObj, podObj.GetNamespace(), metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: newPod.GetName()})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, newPod.GetName(), err)
+	}
+	logFields["rerun"] = newPod.GetName()
+	logger.InfoFields("Successfully rerun Pod", logFields)
+
+	return result, status, nil
+}
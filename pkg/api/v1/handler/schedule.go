@@ -0,0 +1,378 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// initCronJob parses the WorkerSchedule from request body and translates it into CronJob.
+func initCronJob(ctx context.Context, r *http.Request) (*batchv1beta1.CronJob, int, error) {
+	if r.Body == nil {
+		return nil, 400, errors.New("No POST parameters found in the request")
+	}
+	ws, err := adapter.InitWorkerSchedule(ctx, r.Body)
+	if err != nil {
+		return nil, 400, fmt.Errorf("Fail to parse JSON params because of %v", err)
+	}
+	if err = resolveWorkerNamespace(r, &ws.WorkerPod); err != nil {
+		return nil, 403, err
+	}
+	cronJob, err := adapter.TranslateWorkerScheduleToCronJob(ctx, ws)
+	if err != nil {
+		return nil, 500, fmt.Errorf("Fail to init CronJob object because of %v", err)
+	}
+
+	return cronJob, 200, nil
+}
+
+// CreateSchedule creates a CronJob which runs the worker on the cron schedule.
+func CreateSchedule(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create schedule", logFields)
+	cronJobObj, status, err := initCronJob(ctx, r)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to init schedule", logFields)
+
+		return result, status, err
+	}
+
+	cronJob, err := apitypes.DefaultKubeClient().BatchV1beta1().CronJobs(cronJobObj.GetNamespace()).Create(ctx, cronJobObj, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	cronJobName := cronJob.GetName()
+	logFields[apitypes.LogWorkerName], logFields[apitypes.LogWorkerNamespace] = cronJobName, cronJob.GetNamespace()
+	result, err = json.Marshal(adapter.TranslateCronJobStatus(cronJob))
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	logger.InfoFields("Successfully create schedule", logFields)
+
+	return result, status, err
+}
+
+// ListSchedules lists the schedules in the namespace. It supports labelSelector, limit and continue like ListPods.
+func ListSchedules(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	query := r.URL.Query()
+	namespace, err := workerNamespace(r)
+	logFields := logger.Fields{
+		apitypes.LogCtxID:           ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerNamespace: namespace,
+		"query":                     query,
+	}
+	logger.InfoFields("Calling ListSchedules", logFields)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	opts := metav1.ListOptions{
+		LabelSelector: query.Get("labelSelector"),
+		Continue:      query.Get("continue"),
+	}
+	limit, err := queryInt64(r, "limit")
+	if err != nil || (limit != nil && *limit <= 0) {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse limit", logFields)
+
+		return result, 400, fmt.Errorf("limit should be a positive integer")
+	}
+	if limit != nil {
+		opts.Limit = *limit
+	}
+
+	cronJobs, err := apitypes.DefaultKubeClient().BatchV1beta1().CronJobs(namespace).List(ctx, opts)
+	if err != nil {
+		errMsg := "Fail to list CronJobs"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsBadRequest(err) || k8serrors.IsResourceExpired(err) {
+			status = 400
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	scheduleList := &v1types.ScheduleList{
+		Items:    []v1types.ScheduleStatus{},
+		Continue: cronJobs.GetContinue(),
+	}
+	for i := range cronJobs.Items {
+		scheduleList.Items = append(scheduleList.Items, *adapter.TranslateCronJobStatus(&cronJobs.Items[i]))
+	}
+	result, err = json.Marshal(scheduleList)
+	if err != nil {
+		errMsg := "Fail to marshal schedule list into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	logger.InfoFields("Successfully list schedules", logFields)
+
+	return result, status, err
+}
+
+// GetSchedule retrieves the spec and status of the schedule.
+func GetSchedule(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	cronJobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: cronJobName,
+	}
+	logger.InfoFields("Calling GetSchedule", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cronJob, err := apitypes.DefaultKubeClient().BatchV1beta1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+	result, err = json.Marshal(adapter.TranslateCronJobStatus(cronJob))
+	if err != nil {
+		errMsg := "Fail to marshal schedule into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	logger.InfoFields("Successfully get schedule", logFields)
+
+	return result, status, err
+}
+
+// UpdateSchedule replaces the spec of the schedule with the WorkerSchedule in request body. The Jobs which are
+// already created are not affected.
+func UpdateSchedule(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	cronJobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: cronJobName,
+	}
+	logger.InfoFields("Calling UpdateSchedule", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	cronJobObj, status, err := initCronJob(ctx, r)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to init schedule", logFields)
+
+		return result, status, err
+	}
+	if cronJobObj.GetNamespace() != namespace {
+		errMsg := "Namespace of schedule can't be changed"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, errors.New(errMsg)
+	}
+
+	cronJobClient := apitypes.DefaultKubeClient().BatchV1beta1().CronJobs(namespace)
+	cronJob, err := cronJobClient.Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+	cronJob.Spec = cronJobObj.Spec
+	adapter.SetScheduleLabel(cronJob)
+	cronJob, err = cronJobClient.Update(ctx, cronJob, metav1.UpdateOptions{})
+	if err != nil {
+		errMsg := "Fail to update CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsConflict(err) {
+			status = 409
+		} else {
+			status = 400
+		}
+
+		return result, status, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+	result, err = json.Marshal(adapter.TranslateCronJobStatus(cronJob))
+	if err != nil {
+		errMsg := "Fail to marshal schedule into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	logger.InfoFields("Successfully update schedule", logFields)
+
+	return result, status, err
+}
+
+// DeleteSchedule deletes the schedule together with its Jobs and workers.
+func DeleteSchedule(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	cronJobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: cronJobName,
+	}
+	logger.InfoFields("Calling DeleteSchedule", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	err = apitypes.DefaultKubeClient().BatchV1beta1().CronJobs(namespace).Delete(ctx, cronJobName, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		errMsg := "Fail to delete CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: cronJobName})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	logger.InfoFields("Successfully delete schedule", logFields)
+
+	return result, status, err
+}
+
+// ListScheduleRuns lists the Jobs created by the schedule, which are kept by the history limits, with their
+// status.
+func ListScheduleRuns(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	cronJobName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: cronJobName,
+	}
+	logger.InfoFields("Calling ListScheduleRuns", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	kubeClient := apitypes.DefaultKubeClient()
+	cronJob, err := kubeClient.BatchV1beta1().CronJobs(namespace).Get(ctx, cronJobName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get CronJob"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+	jobs, err := kubeClient.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelSchedule + "=" + cronJobName,
+	})
+	if err != nil {
+		errMsg := "Fail to list Jobs"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	scheduleRuns := &v1types.ScheduleRuns{
+		Id:   cronJobName,
+		Runs: []v1types.JobStatus{},
+	}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !adapter.IsScheduledJob(cronJob, job) {
+			continue
+		}
+		pods, err := listJobPods(job)
+		if err != nil {
+			errMsg := "Fail to list Job Pods"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, job.GetName(), err)
+		}
+		scheduleRuns.Runs = append(scheduleRuns.Runs, *adapter.TranslateJobStatus(job, pods))
+	}
+	sort.SliceStable(scheduleRuns.Runs, func(i, j int) bool {
+		return scheduleRuns.Runs[i].CreationTime.After(scheduleRuns.Runs[j].CreationTime)
+	})
+	result, err = json.Marshal(scheduleRuns)
+	if err != nil {
+		errMsg := "Fail to marshal schedule runs into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, cronJobName, err)
+	}
+
+	logger.InfoFields("Successfully list schedule runs", logFields)
+
+	return result, status, err
+}
@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getServiceStatus retrieves the Deployment, Service and Pods of service and aggregates their status.
+func getServiceStatus(ctx context.Context, namespace, serviceID string) (*v1types.ServiceStatus, int, error) {
+	kubeClient := apitypes.DefaultKubeClient()
+	deployment, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, serviceID, metav1.GetOptions{})
+	if err != nil || deployment.GetLabels()[apitypes.LabelService] != serviceID {
+		return nil, 404, fmt.Errorf("Fail to get Deployment for %s because of %v", serviceID, err)
+	}
+	service, err := kubeClient.CoreV1().Services(namespace).Get(ctx, serviceID, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		service, err = nil, nil
+	}
+	if err != nil {
+		return nil, 500, fmt.Errorf("Fail to get Service for %s because of %v", serviceID, err)
+	}
+	pods, err := apitypes.DefaultPodClient().ListPods(namespace, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{apitypes.LabelService: serviceID}).String(),
+	})
+	if err != nil {
+		return nil, 500, fmt.Errorf("Fail to list Pods for %s because of %v", serviceID, err)
+	}
+
+	return adapter.TranslateServiceStatus(deployment, service, pods.Items), 200, nil
+}
+
+// patchServiceDeployment applies the strategic merge patch to the Deployment of service and returns the service
+// status after patch.
+func patchServiceDeployment(ctx context.Context, namespace, serviceID string, patch interface{}) (
+	*v1types.ServiceStatus, int, error) {
+	if _, status, err := getServiceStatus(ctx, namespace, serviceID); err != nil {
+		return nil, status, err
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, 500, err
+	}
+	_, err = apitypes.DefaultKubeClient().AppsV1().Deployments(namespace).Patch(ctx, serviceID,
+		types.StrategicMergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return nil, 400, fmt.Errorf("Fail to patch Deployment for %s because of %v", serviceID, err)
+	}
+
+	return getServiceStatus(ctx, namespace, serviceID)
+}
+
+// CreateService creates the Deployment which runs the worker as long-running service and the Service of its ports.
+func CreateService(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create service", logFields)
+	if r.Body == nil {
+		errMsg := "No POST parameters found in the request"
+		logger.Error(errMsg)
+
+		return result, 400, errors.New(errMsg)
+	}
+	ws, err := adapter.InitWorkerService(ctx, r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if err = resolveWorkerNamespace(r, &ws.WorkerPod); err != nil {
+		logFields[apitypes.LogWorkerNamespace] = ws.Namespace
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	serviceID := adapter.ServiceID(ctx)
+	logFields[apitypes.LogWorkerName], logFields[apitypes.LogWorkerNamespace] = serviceID, ws.Namespace
+	deploymentObj, serviceObj, err := adapter.TranslateWorkerServiceToDeployment(ctx, serviceID, ws)
+	if err != nil {
+		errMsg := "Fail to init Deployment object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	kubeClient := apitypes.DefaultKubeClient()
+	deployment, err := kubeClient.AppsV1().Deployments(ws.Namespace).Create(ctx, deploymentObj, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create Deployment"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	var service *corev1.Service
+	if serviceObj != nil {
+		service, err = kubeClient.CoreV1().Services(ws.Namespace).Create(ctx, serviceObj, metav1.CreateOptions{})
+		if err != nil {
+			errMsg := "Fail to create Service"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+			if err := kubeClient.AppsV1().Deployments(ws.Namespace).Delete(ctx, serviceID, metav1.DeleteOptions{}); err != nil {
+				logFields[logger.ERROR] = err
+				logger.ErrorFields("Fail to delete Deployment", logFields)
+			}
+
+			return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+		}
+	}
+	result, err = json.Marshal(adapter.TranslateServiceStatus(deployment, service, nil))
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully create service", logFields)
+
+	return result, status, err
+}
+
+// GetServiceStatus retrieves the rollout and readiness of service.
+func GetServiceStatus(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	serviceID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: serviceID,
+	}
+	logger.InfoFields("Calling GetServiceStatus", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	serviceStatus, status, err := getServiceStatus(ctx, namespace, serviceID)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to get service status", logFields)
+
+		return result, status, err
+	}
+	result, err = json.Marshal(serviceStatus)
+	if err != nil {
+		errMsg := "Fail to marshal service status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully get service status", logFields)
+
+	return result, status, err
+}
+
+// ScaleService changes the number of replicas of service.
+func ScaleService(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	serviceID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: serviceID,
+	}
+	logger.InfoFields("Calling ScaleService", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	scale := &v1types.ServiceScale{}
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(scale) != nil || scale.Replicas == nil || *scale.Replicas < 0 {
+		errMsg := "replicas should be a non-negative integer"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, errors.New(errMsg)
+	}
+	logFields["replicas"] = *scale.Replicas
+
+	serviceStatus, status, err := patchServiceDeployment(ctx, namespace, serviceID, map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": *scale.Replicas},
+	})
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to scale service", logFields)
+
+		return result, status, err
+	}
+	result, err = json.Marshal(serviceStatus)
+	if err != nil {
+		errMsg := "Fail to marshal service status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully scale service", logFields)
+
+	return result, status, err
+}
+
+// RestartService restarts all workers of service by rolling update.
+func RestartService(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	serviceID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: serviceID,
+	}
+	logger.InfoFields("Calling RestartService", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	serviceStatus, status, err := patchServiceDeployment(ctx, namespace, serviceID, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						apitypes.AnnotationRestartedAt: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to restart service", logFields)
+
+		return result, status, err
+	}
+	result, err = json.Marshal(serviceStatus)
+	if err != nil {
+		errMsg := "Fail to marshal service status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully restart service", logFields)
+
+	return result, status, err
+}
+
+// RolloutService rolls out the new image version of service.
+func RolloutService(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	serviceID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: serviceID,
+	}
+	logger.InfoFields("Calling RolloutService", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	rollout := &v1types.ServiceRollout{}
+	if r.Body == nil || json.NewDecoder(r.Body).Decode(rollout) != nil || rollout.ImageVersion == "" {
+		errMsg := "imageversion is a mandatory parameter"
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, errors.New(errMsg)
+	}
+
+	deployment, err := apitypes.DefaultKubeClient().AppsV1().Deployments(namespace).Get(ctx, serviceID, metav1.GetOptions{})
+	if err != nil || deployment.GetLabels()[apitypes.LabelService] != serviceID {
+		errMsg := "Fail to get Deployment"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if rollout.Image == "" {
+		// Strip the tag of current image, which is after the last colon unless the colon is of registry port.
+		rollout.Image = container.Image
+		if i := strings.LastIndex(container.Image, ":"); i > strings.LastIndex(container.Image, "/") {
+			rollout.Image = container.Image[:i]
+		}
+	}
+	image := rollout.Image + ":" + rollout.ImageVersion
+	logFields["image"] = image
+
+	serviceStatus, status, err := patchServiceDeployment(ctx, namespace, serviceID, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []map[string]string{{"name": container.Name, "image": image}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to roll out service", logFields)
+
+		return result, status, err
+	}
+	result, err = json.Marshal(serviceStatus)
+	if err != nil {
+		errMsg := "Fail to marshal service status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully roll out service", logFields)
+
+	return result, status, err
+}
+
+// DeleteService deletes the Deployment, Service and workers of service.
+func DeleteService(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	serviceID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: serviceID,
+	}
+	logger.InfoFields("Calling DeleteService", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	if _, status, err = getServiceStatus(ctx, namespace, serviceID); err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to get service", logFields)
+
+		return result, status, err
+	}
+	kubeClient := apitypes.DefaultKubeClient()
+	err = kubeClient.CoreV1().Services(namespace).Delete(ctx, serviceID, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		errMsg := "Fail to delete Service"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = kubeClient.AppsV1().Deployments(namespace).Delete(ctx, serviceID, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		errMsg := "Fail to delete Deployment"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: serviceID})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, serviceID, err)
+	}
+
+	logger.InfoFields("Successfully delete service", logFields)
+
+	return result, status, err
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	contentTypeSSE  string = "text/event-stream"
+	contentTypeText string = "text/plain; charset=utf-8"
+
+	sseEventEnd string = "end"
+)
+
+// wantSSE returns true if the client asks for Server-Sent Events by Accept header or format=sse.
+func wantSSE(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), contentTypeSSE) ||
+		strings.EqualFold(r.URL.Query().Get("format"), "sse")
+}
+
+// sseWriter writes Server-Sent Events into the response and flushes them to the client immediately.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newSSEWriter writes the headers of event stream and returns a sseWriter.
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported by the response writer")
+	}
+	header := w.Header()
+	header.Set("Content-Type", contentTypeSSE)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+// WriteEvent writes one event. The id and event fields are omitted if they are empty and multi-line data is
+// split into several data fields.
+func (s *sseWriter) WriteEvent(id, event, data string) error {
+	buf := new(bytes.Buffer)
+	if id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(buf, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// flushWriter flushes every write to the client so the chunked response is delivered in time.
+type flushWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil {
+		fw.flusher.Flush()
+	}
+
+	return n, err
+}
+
+// newFlushWriter writes the headers with the content type and returns a flushWriter.
+func newFlushWriter(w http.ResponseWriter, contentType string) (*flushWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming is not supported by the response writer")
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &flushWriter{w: w, flusher: flusher}, nil
+}
+
+// WriteComment writes a comment line which is ignored by clients. It's used to keep the connection alive.
+func (s *sseWriter) WriteComment(comment string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+
+	return nil
+}
+
+// writeJSON writes the value in JSON into the response with the status. It's used by handlers which write the
+// response by themselves.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	defaultTerminalShell string = "/bin/sh"
+
+	// debugPodLifetime is the longest time a debug Pod lives, in case it isn't deleted after the session.
+	debugPodLifetime time.Duration = time.Hour
+	// debugPodStartTimeout is the maximum length of time to wait for debug Pod to run.
+	debugPodStartTimeout time.Duration = 2 * time.Minute
+)
+
+// terminalSizeQueue passes the resize messages of client to the executor.
+type terminalSizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+	done  chan struct{}
+}
+
+func newTerminalSizeQueue() *terminalSizeQueue {
+	return &terminalSizeQueue{
+		sizes: make(chan remotecommand.TerminalSize, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+// Next blocks until the terminal is resized. It returns nil when the session ends.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-q.sizes:
+		return &size
+	case <-q.done:
+		return nil
+	}
+}
+
+// Push keeps the latest size only so the reader of WebSocket is never blocked.
+func (q *terminalSizeQueue) Push(msg *v1types.StreamMessage) {
+	if msg.Type != apitypes.StreamMessageResize || msg.Cols == 0 || msg.Rows == 0 {
+		return
+	}
+	size := remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}
+	select {
+	case <-q.sizes:
+	default:
+	}
+	q.sizes <- size
+}
+
+func (q *terminalSizeQueue) Close() {
+	close(q.done)
+}
+
+// createDebugPod starts a Pod with the image, env, mounts and resources of the worker but sleeps instead of
+// running the worker command, so a shell can be opened in it. It returns the running debug Pod.
+func createDebugPod(ctx context.Context, pod *corev1.Pod) (*corev1.Pod, error) {
+	wp := adapter.TranslatePodToWorkerPod(ctx, pod)
+	wp.Name = pod.Spec.Containers[0].Name
+	wp.Prefix = pod.GetName() + "-debug-"
+	wp.Cmd = []string{"sleep", fmt.Sprintf("%d", int64(debugPodLifetime.Seconds()))}
+	wp.Labels = map[string]string{apitypes.LabelDebugFor: pod.GetName()}
+	wp.Annotations = map[string]string{}
+	// The debug Pod isn't a worker, so it's neither timed out, retried nor queued and preempted with priority.
+	wp.MaxPending, wp.MaxRuntime, wp.Priority, wp.RetryPolicy = 0, 0, 0, nil
+	debugPodObj, err := adapter.TranslateWorkerPodToPod(ctx, wp)
+	if err != nil {
+		return nil, err
+	}
+	delete(debugPodObj.Labels, apitypes.LabelManagedWorker)
+	debugPodObj.Spec.PriorityClassName = ""
+	deadline := int64(debugPodLifetime.Seconds())
+	debugPodObj.Spec.ActiveDeadlineSeconds = &deadline
+
+	debugPod, err := apitypes.DefaultPodClient().CreatePod(debugPodObj, debugPodObj.GetNamespace(), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, debugPodStartTimeout)
+	defer cancel()
+	workerStatus, _, err := waitForWorkerState(waitCtx, debugPod, apitypes.WorkerStatusRunning)
+	if err == nil && workerStatus.TimedOut {
+		err = fmt.Errorf("debug Pod isn't running in %v", debugPodStartTimeout)
+	} else if err == nil && workerStatus.State != apitypes.WorkerStatusRunning {
+		err = fmt.Errorf("debug Pod is %s because of %s", workerStatus.State, workerStatus.Reason)
+	}
+	if err != nil {
+		deleteDebugPod(debugPod)
+
+		return nil, err
+	}
+
+	return debugPod, nil
+}
+
+// deleteDebugPod deletes the debug Pod immediately.
+func deleteDebugPod(debugPod *corev1.Pod) error {
+	var gracePeriod int64
+
+	return apitypes.DefaultPodClient().DeletePodWithCheck(debugPod.GetNamespace(), debugPod.GetName(),
+		metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+}
+
+// AttachPodTerminal opens an interactive terminal over WebSocket. The shell is given by query parameter shell and
+// runs in the running worker, or in a new debug Pod using the worker's image if debug=true. The client sends
+// stdin and resize messages, and receives stdout messages and an exit message when the shell exits.
+func AttachPodTerminal(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling AttachPodTerminal", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+	query := r.URL.Query()
+	shell := query.Get("shell")
+	if shell == "" {
+		shell = defaultTerminalShell
+	}
+	debug, err := queryBool(r, "debug", false)
+	if err != nil {
+		return 400, err
+	}
+	logFields["shell"], logFields["debug"] = shell, debug
+
+	targetPod, container := podName, query.Get("container")
+	if debug {
+		pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+		if err != nil {
+			errMsg := "Fail to get Pod"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+		}
+		debugPod, err := createDebugPod(ctx, pod)
+		if err != nil {
+			errMsg := "Fail to start debug Pod"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+		}
+		defer deleteDebugPod(debugPod)
+		targetPod, container = debugPod.GetName(), debugPod.Spec.Containers[0].Name
+		logFields["debugPod"] = targetPod
+	} else {
+		if _, container, status, err = getRunningPod(namespace, podName, container); err != nil {
+			logFields[logger.ERROR] = err
+			logger.ErrorFields("Fail to find running container", logFields)
+
+			return status, err
+		}
+	}
+	logFields["container"] = container
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		// The upgrader has replied to the client.
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to upgrade to WebSocket", logFields)
+
+		return http.StatusBadRequest, nil
+	}
+	defer conn.Close()
+
+	sizeQueue := newTerminalSizeQueue()
+	defer sizeQueue.Close()
+	// The read loop ends when the client goes away, which closes the shell.
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stdinReader, stdinWriter := io.Pipe()
+	go func() {
+		conn.ReadLoop(stdinWriter, sizeQueue.Push)
+		cancel()
+	}()
+	exitCode, err := execInPod(&execOptions{
+		ctx:       execCtx,
+		namespace: namespace,
+		podName:   targetPod,
+		container: container,
+		cmd:       []string{shell},
+		stdin:     stdinReader,
+		stdout:    conn.Writer(apitypes.StreamMessageStdout),
+		tty:       true,
+		sizeQueue: sizeQueue,
+	})
+	stdinReader.CloseWithError(io.EOF)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Terminal session is interrupted", logFields)
+		conn.WriteMessage(&v1types.StreamMessage{Type: apitypes.StreamMessageError, Data: err.Error()})
+
+		return status, nil
+	}
+	conn.WriteMessage(&v1types.StreamMessage{Type: apitypes.StreamMessageExit, ExitCode: &exitCode})
+
+	logFields["exitCode"] = exitCode
+	logger.InfoFields("Successfully close terminal session", logFields)
+
+	return status, nil
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	defaultWaitTimeout time.Duration = 60 * time.Second
+	maxWaitTimeout     time.Duration = time.Hour
+)
+
+// WaitPod holds the request until the worker reaches the target state given by query parameter for, which is
+// Terminated by default, or the timeout expires. It returns the WorkerStatus when the worker reaches the state, or
+// the current one with timedOut set when the timeout expires. Waiting for Running also returns if the worker has
+// terminated because it'll never be running again.
+func WaitPod(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling WaitPod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	target := apitypes.WorkerStatusTerminated
+	if value := r.URL.Query().Get("for"); value != "" {
+		switch {
+		case strings.EqualFold(value, apitypes.WorkerStatusTerminated):
+			target = apitypes.WorkerStatusTerminated
+		case strings.EqualFold(value, apitypes.WorkerStatusRunning):
+			target = apitypes.WorkerStatusRunning
+		default:
+			return result, 400, fmt.Errorf("invalid value %q of query parameter for, expect %s or %s",
+				value, apitypes.WorkerStatusTerminated, apitypes.WorkerStatusRunning)
+		}
+	}
+	timeout, err := queryDuration(r, "timeout", defaultWaitTimeout)
+	if err != nil {
+		return result, 400, err
+	}
+	if timeout <= 0 || timeout > maxWaitTimeout {
+		return result, 400, fmt.Errorf("timeout should be in range (0, %v]", maxWaitTimeout)
+	}
+	logFields["for"], logFields["timeout"] = target, timeout.String()
+
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	workerStatus, status, err := waitForWorkerState(waitCtx, pod, target)
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to wait for Pod", logFields)
+
+		return result, status, fmt.Errorf("Fail to wait for %s because of %v", podName, err)
+	}
+
+	result, err = json.Marshal(workerStatus)
+	if err != nil {
+		errMsg := "Fail to marshal Pod status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	logFields["timedOut"] = workerStatus.TimedOut
+	logger.InfoFields("Successfully wait for Pod", logFields)
+
+	return result, status, err
+}
+
+// reachWorkerState returns true if the worker is in target state or it won't change any more.
+func reachWorkerState(workerStatus *v1types.WorkerStatus, target string) bool {
+	return workerStatus.State == target || workerStatus.IsTerminated()
+}
+
+// waitForWorkerState watches the Pod until the worker reaches the target state. If the context expires, it returns
+// the current status with TimedOut set. It returns the HTTP status together with the error if the Pod is deleted.
+func waitForWorkerState(ctx context.Context, pod *corev1.Pod, target string) (*v1types.WorkerStatus, int, error) {
+	workerStatus := adapter.TranslatePodStatus(pod)
+	resourceVersion := pod.GetResourceVersion()
+	for !reachWorkerState(workerStatus, target) {
+		watcher, err := apitypes.DefaultKubeClient().CoreV1().Pods(pod.GetNamespace()).Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", pod.GetName()).String(),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				workerStatus.TimedOut = true
+				return workerStatus, http.StatusOK, nil
+			}
+
+			return workerStatus, 500, err
+		}
+		reached, status, err := consumeWaitEvents(ctx, watcher, target, &workerStatus, &resourceVersion)
+		watcher.Stop()
+		if err != nil {
+			return workerStatus, status, err
+		}
+		if reached || workerStatus.TimedOut {
+			break
+		}
+		if resourceVersion == "" {
+			// The resource version expires, start over from the current Pod.
+			current, err := apitypes.DefaultPodClient().GetPod(pod.GetNamespace(), pod.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return workerStatus, 404, err
+			}
+			workerStatus, resourceVersion = adapter.TranslatePodStatus(current), current.GetResourceVersion()
+		}
+	}
+
+	return workerStatus, http.StatusOK, nil
+}
+
+// consumeWaitEvents handles the watch events until the worker reaches the target state or the watch is closed.
+func consumeWaitEvents(ctx context.Context, watcher watch.Interface, target string,
+	workerStatus **v1types.WorkerStatus, resourceVersion *string) (bool, int, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			(*workerStatus).TimedOut = true
+			return false, http.StatusOK, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, http.StatusOK, nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					*workerStatus, *resourceVersion = adapter.TranslatePodStatus(pod), pod.GetResourceVersion()
+					if reachWorkerState(*workerStatus, target) {
+						return true, http.StatusOK, nil
+					}
+				}
+			case watch.Deleted:
+				return false, http.StatusGone, fmt.Errorf("worker is deleted when it's %s", (*workerStatus).State)
+			case watch.Error:
+				*resourceVersion = ""
+
+				return false, http.StatusOK, nil
+			}
+		}
+	}
+}
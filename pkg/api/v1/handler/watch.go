@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	sseEventStatus  string = "status"
+	sseEventDeleted string = "deleted"
+
+	// watchHeartbeatPeriod is the interval to send heartbeat comments when there is no status change.
+	watchHeartbeatPeriod time.Duration = 30 * time.Second
+)
+
+// WatchPod pushes every WorkerStatus transition of the worker to the client in Server-Sent Events. The event ID
+// is the resource version of the Pod so the client can resume the stream by Last-Event-ID header. The stream is
+// closed after the worker terminates or is deleted.
+func WatchPod(ctx context.Context, w http.ResponseWriter, r *http.Request) (status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	podName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: podName,
+	}
+	logger.InfoFields("Calling WatchPod", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return 403, err
+	}
+
+	// Make sure the Pod exists before opening the stream.
+	pod, err := apitypes.DefaultPodClient().GetPod(namespace, podName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Pod"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return 404, fmt.Errorf("%s for %s because of %v", errMsg, podName, err)
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		return 500, err
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	pw := &podWatcher{
+		ctx:       ctx,
+		sse:       sse,
+		namespace: namespace,
+		podName:   podName,
+	}
+	if lastEventID == "" || adapter.TranslatePodStatus(pod).IsTerminated() {
+		// Send the current status first for a new stream. If the worker has already terminated, there won't be
+		// further events to resume from so the final status is sent directly.
+		err = pw.send(pod)
+	} else {
+		pw.resourceVersion = lastEventID
+	}
+	if err == nil && !pw.done {
+		err = pw.run()
+	}
+	if err != nil && ctx.Err() == nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Watch stream is interrupted", logFields)
+	}
+
+	logger.InfoFields("Successfully watch Pod", logFields)
+
+	return status, nil
+}
+
+// podWatcher watches one Pod and writes its status transitions into event stream.
+type podWatcher struct {
+	ctx             context.Context
+	sse             *sseWriter
+	namespace       string
+	podName         string
+	resourceVersion string
+	lastStatus      []byte
+	done            bool
+}
+
+// run keeps watching the Pod until the worker terminates, the Pod is deleted or the client disconnects. The
+// Kubernetes watch is re-established from the last resource version if it's closed by API server.
+func (pw *podWatcher) run() error {
+	heartbeat := time.NewTicker(watchHeartbeatPeriod)
+	defer heartbeat.Stop()
+	for !pw.done {
+		watcher, err := apitypes.DefaultKubeClient().CoreV1().Pods(pw.namespace).Watch(pw.ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", pw.podName).String(),
+			ResourceVersion: pw.resourceVersion,
+		})
+		if err != nil {
+			return err
+		}
+		err = pw.consume(watcher, heartbeat)
+		watcher.Stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// consume handles the events until the watch channel is closed.
+func (pw *podWatcher) consume(watcher watch.Interface, heartbeat *time.Ticker) error {
+	for {
+		select {
+		case <-pw.ctx.Done():
+			pw.done = true
+
+			return nil
+		case <-heartbeat.C:
+			if err := pw.sse.WriteComment("heartbeat"); err != nil {
+				return err
+			}
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					if err := pw.send(pod); err != nil {
+						return err
+					}
+				}
+			case watch.Deleted:
+				pw.done = true
+				resourceVersion := pw.resourceVersion
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					resourceVersion = pod.GetResourceVersion()
+				}
+
+				return pw.sse.WriteEvent(resourceVersion, sseEventDeleted, "")
+			case watch.Error:
+				err := k8serrors.FromObject(event.Object)
+				if !k8serrors.IsResourceExpired(err) && !k8serrors.IsGone(err) {
+					return err
+				}
+				// The resource version to resume is too old. Start over from the current Pod.
+				pod, err := apitypes.DefaultPodClient().GetPod(pw.namespace, pw.podName, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+
+				return pw.send(pod)
+			}
+			if pw.done {
+				return nil
+			}
+		}
+	}
+}
+
+// send writes the status of Pod if it differs from the last one, and ends the stream once the worker terminates.
+func (pw *podWatcher) send(pod *corev1.Pod) error {
+	pw.resourceVersion = pod.GetResourceVersion()
+	workerStatus := adapter.TranslatePodStatus(pod)
+	data, err := json.Marshal(workerStatus)
+	if err != nil {
+		return err
+	}
+	if string(data) != string(pw.lastStatus) {
+		if err = pw.sse.WriteEvent(pw.resourceVersion, sseEventStatus, string(data)); err != nil {
+			return err
+		}
+		pw.lastStatus = data
+	}
+	if workerStatus.IsTerminated() {
+		pw.done = true
+
+		return pw.sse.WriteEvent(pw.resourceVersion, sseEventEnd, "")
+	}
+
+	return nil
+}
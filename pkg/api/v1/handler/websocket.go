@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// wsConn wraps the WebSocket connection to exchange StreamMessage. Only one goroutine may write into WebSocket
+// at a time so the writes are serialized by the lock.
+type wsConn struct {
+	conn *websocket.Conn
+	lock sync.Mutex
+}
+
+// upgradeWebSocket upgrades the HTTP connection to WebSocket. The upgrader replies to the client on failure.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wsConn{conn: conn}, nil
+}
+
+// WriteMessage sends one message to the client.
+func (c *wsConn) WriteMessage(msg *v1types.StreamMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Writer returns an io.Writer which sends everything written as the given type of message.
+func (c *wsConn) Writer(msgType string) io.Writer {
+	return &wsWriter{conn: c, msgType: msgType}
+}
+
+// Close sends the close frame and closes the connection.
+func (c *wsConn) Close() error {
+	c.lock.Lock()
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	c.lock.Unlock()
+
+	return c.conn.Close()
+}
+
+// ReadLoop reads messages from the client and writes stdin data into the pipe until the client closes the
+// connection. Stdin data is dropped if the pipe is nil. Other messages are passed to handle if it's not nil.
+func (c *wsConn) ReadLoop(stdin *io.PipeWriter, handle func(*v1types.StreamMessage)) {
+	if stdin != nil {
+		defer stdin.Close()
+	}
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		msg := &v1types.StreamMessage{}
+		if err = json.Unmarshal(data, msg); err != nil {
+			// Plain text is taken as stdin.
+			msg.Type, msg.Data = apitypes.StreamMessageStdin, string(data)
+		}
+		if msg.Type == apitypes.StreamMessageStdin {
+			if stdin == nil {
+				continue
+			}
+			if _, err = stdin.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		} else if handle != nil {
+			handle(msg)
+		}
+	}
+}
+
+type wsWriter struct {
+	conn    *wsConn
+	msgType string
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	if err := w.conn.WriteMessage(&v1types.StreamMessage{Type: w.msgType, Data: string(p)}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
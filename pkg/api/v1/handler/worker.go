@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// workerClient returns the dynamic client of Worker custom resources in CRD namespace.
+func workerClient() dynamic.ResourceInterface {
+	return apitypes.DefaultDynamicClient().Resource(apitypes.WorkerResource).Namespace(config.GetConfig().CRDNamespace)
+}
+
+// CreateWorker creates a Worker custom resource which is reconciled into Pod by the Worker controller.
+func CreateWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create Worker", logFields)
+	if r.Body == nil {
+		errMsg := "No POST parameters found in the request"
+		logger.Error(errMsg)
+
+		return result, 400, errors.New(errMsg)
+	}
+	worker, err := adapter.InitWorker(ctx, r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	if ns := worker.Spec.Namespace; ns != "" && !config.GetConfig().IsNamespaceAllowed(ns) {
+		logFields[apitypes.LogWorkerNamespace] = ns
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, fmt.Errorf("namespace %s is not allowed", ns)
+	}
+	obj, err := adapter.TranslateWorkerToUnstructured(worker)
+	if err != nil {
+		errMsg := "Fail to init Worker object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	obj, err = workerClient().Create(ctx, obj, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create Worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	workerName := obj.GetName()
+	logFields[apitypes.LogWorkerName] = workerName
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: workerName})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+
+	logger.InfoFields("Successfully create Worker", logFields)
+
+	return result, status, err
+}
+
+// GetWorker retrieves the spec and status of the Worker custom resource.
+func GetWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	workerName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: workerName,
+	}
+	logger.InfoFields("Calling GetWorker", logFields)
+
+	obj, err := workerClient().Get(ctx, workerName, metav1.GetOptions{})
+	if err != nil {
+		errMsg := "Fail to get Worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+	worker, err := adapter.TranslateUnstructuredToWorker(obj)
+	if err != nil {
+		errMsg := "Fail to parse Worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+	result, err = json.Marshal(worker)
+	if err != nil {
+		errMsg := "Fail to marshal Worker into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+
+	logger.InfoFields("Successfully get Worker", logFields)
+
+	return result, status, err
+}
+
+// ListWorkers lists the Worker custom resources. It supports labelSelector, limit and continue like ListPods.
+func ListWorkers(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	query := r.URL.Query()
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+		"query":           query,
+	}
+	logger.InfoFields("Calling ListWorkers", logFields)
+
+	opts := metav1.ListOptions{
+		LabelSelector: query.Get("labelSelector"),
+		Continue:      query.Get("continue"),
+	}
+	limit, err := queryInt64(r, "limit")
+	if err != nil || (limit != nil && *limit <= 0) {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Fail to parse limit", logFields)
+
+		return result, 400, fmt.Errorf("limit should be a positive integer")
+	}
+	if limit != nil {
+		opts.Limit = *limit
+	}
+
+	objs, err := workerClient().List(ctx, opts)
+	if err != nil {
+		errMsg := "Fail to list Workers"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsBadRequest(err) || k8serrors.IsResourceExpired(err) {
+			status = 400
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	workerList := &v1types.WorkerResourceList{
+		Items:    []v1types.Worker{},
+		Continue: objs.GetContinue(),
+	}
+	for i := range objs.Items {
+		worker, err := adapter.TranslateUnstructuredToWorker(&objs.Items[i])
+		if err != nil {
+			errMsg := "Fail to parse Worker"
+			logFields[logger.ERROR] = err
+			logger.ErrorFields(errMsg, logFields)
+
+			return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, objs.Items[i].GetName(), err)
+		}
+		workerList.Items = append(workerList.Items, *worker)
+	}
+	result, err = json.Marshal(workerList)
+	if err != nil {
+		errMsg := "Fail to marshal Worker list into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	logger.InfoFields("Successfully list Workers", logFields)
+
+	return result, status, err
+}
+
+// DeleteWorker deletes the Worker custom resource. Its Pod is deleted by the Worker controller.
+func DeleteWorker(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	workerName := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: workerName,
+	}
+	logger.InfoFields("Calling DeleteWorker", logFields)
+
+	err = workerClient().Delete(ctx, workerName, metav1.DeleteOptions{})
+	if err != nil {
+		errMsg := "Fail to delete Worker"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: workerName})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workerName, err)
+	}
+
+	logger.InfoFields("Successfully delete Worker", logFields)
+
+	return result, status, err
+}
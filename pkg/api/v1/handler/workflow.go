@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CreateWorkflow persists the workflow, which is then driven by the workflow controller.
+func CreateWorkflow(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	logFields := logger.Fields{
+		apitypes.LogCtxID: ctx.Value(apitypes.LogCtxID),
+	}
+	logger.InfoFields("Start to create workflow", logFields)
+	if r.Body == nil {
+		errMsg := "No POST parameters found in the request"
+		logger.Error(errMsg)
+
+		return result, 400, errors.New(errMsg)
+	}
+	wf, err := adapter.InitWorkflow(ctx, r.Body)
+	if err != nil {
+		errMsg := "Fail to parse JSON POST params"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	wp := &v1types.WorkerPod{Namespace: wf.Namespace}
+	if err = resolveWorkerNamespace(r, wp); err != nil {
+		logFields[apitypes.LogWorkerNamespace] = wp.Namespace
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+	wf.Namespace = wp.Namespace
+	workflowID := adapter.WorkflowID(ctx)
+	logFields[apitypes.LogWorkerName], logFields[apitypes.LogWorkerNamespace] = workflowID, wf.Namespace
+	cm, err := adapter.TranslateWorkflowToConfigMap(wf, adapter.NewWorkflowStatus(workflowID, wf.Namespace, wf))
+	if err != nil {
+		errMsg := "Fail to init workflow object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+
+	_, err = apitypes.DefaultKubeClient().CoreV1().ConfigMaps(wf.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil {
+		errMsg := "Fail to create workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s because of %v", errMsg, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: workflowID})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+
+	logger.InfoFields("Successfully create workflow", logFields)
+
+	return result, status, err
+}
+
+// GetWorkflowStatus retrieves the status of workflow and its steps.
+func GetWorkflowStatus(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	workflowID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: workflowID,
+	}
+	logger.InfoFields("Calling GetWorkflowStatus", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cm, err := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace).Get(ctx, workflowID, metav1.GetOptions{})
+	if err != nil || cm.GetLabels()[apitypes.LabelWorkflow] != workflowID {
+		errMsg := "Fail to get workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	_, workflowStatus, err := adapter.TranslateConfigMapToWorkflow(cm)
+	if err != nil {
+		errMsg := "Fail to parse workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	result, err = json.Marshal(workflowStatus)
+	if err != nil {
+		errMsg := "Fail to marshal workflow status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+
+	logger.InfoFields("Successfully get workflow status", logFields)
+
+	return result, status, err
+}
+
+// RetryWorkflowStep runs the failed step again together with its descendants which haven't succeeded.
+func RetryWorkflowStep(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	workflowID, stepName := vars["key"], vars["step"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: workflowID,
+		"step":                 stepName,
+	}
+	logger.InfoFields("Calling RetryWorkflowStep", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	cmClient := apitypes.DefaultKubeClient().CoreV1().ConfigMaps(namespace)
+	cm, err := cmClient.Get(ctx, workflowID, metav1.GetOptions{})
+	if err != nil || cm.GetLabels()[apitypes.LabelWorkflow] != workflowID {
+		errMsg := "Fail to get workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	wf, workflowStatus, err := adapter.TranslateConfigMapToWorkflow(cm)
+	if err != nil {
+		errMsg := "Fail to parse workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	if err = adapter.RetryWorkflowStep(wf, workflowStatus, stepName); err != nil {
+		errMsg := "Fail to retry workflow step"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 400, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	if err = adapter.SetConfigMapWorkflowStatus(cm, workflowStatus); err != nil {
+		errMsg := "Fail to init workflow object"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	if _, err = cmClient.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		errMsg := "Fail to update workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+		if k8serrors.IsConflict(err) {
+			status = 409
+		} else {
+			status = 500
+		}
+
+		return result, status, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	result, err = json.Marshal(workflowStatus)
+	if err != nil {
+		errMsg := "Fail to marshal workflow status into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+
+	logger.InfoFields("Successfully retry workflow step", logFields)
+
+	return result, status, err
+}
+
+// DeleteWorkflow deletes the workflow and the workers of its steps.
+func DeleteWorkflow(ctx context.Context, r *http.Request) (result []byte, status int, err error) {
+	status = http.StatusOK
+	vars := mux.Vars(r)
+	workflowID := vars["key"]
+	logFields := logger.Fields{
+		apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+		apitypes.LogWorkerName: workflowID,
+	}
+	logger.InfoFields("Calling DeleteWorkflow", logFields)
+	namespace, err := workerNamespace(r)
+	logFields[apitypes.LogWorkerNamespace] = namespace
+	if err != nil {
+		logFields[logger.ERROR] = err
+		logger.ErrorFields("Namespace is not allowed", logFields)
+
+		return result, 403, err
+	}
+
+	kubeClient := apitypes.DefaultKubeClient()
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, workflowID, metav1.GetOptions{})
+	if err != nil || cm.GetLabels()[apitypes.LabelWorkflow] != workflowID {
+		errMsg := "Fail to get workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 404, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	// Delete the ConfigMap first so that the workflow controller doesn't start new steps.
+	err = kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, workflowID, metav1.DeleteOptions{})
+	if err != nil {
+		errMsg := "Fail to delete workflow"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	// The running steps whose Pods are still in admission queue are dropped from it.
+	if _, wfStatus, err := adapter.TranslateConfigMapToWorkflow(cm); err == nil {
+		for _, stepStatus := range wfStatus.Steps {
+			if stepStatus.Phase != apitypes.StepPhaseRunning || stepStatus.Worker == "" {
+				continue
+			}
+			if err = dropQueuedPod(ctx, namespace, stepStatus.Worker); err != nil {
+				logger.ErrorFields("Fail to drop queued Pod of workflow step", logger.Fields{
+					apitypes.LogCtxID:      ctx.Value(apitypes.LogCtxID),
+					apitypes.LogWorkerName: stepStatus.Worker,
+					logger.ERROR:           err,
+				})
+			}
+		}
+	}
+	err = kubeClient.CoreV1().Pods(namespace).DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{apitypes.LabelWorkflow: workflowID}).String(),
+	})
+	if err != nil {
+		errMsg := "Fail to delete workflow Pods"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+	result, err = json.Marshal(&v1types.WorkerDetails{Id: workflowID})
+	if err != nil {
+		errMsg := "Fail to marshal result into JSON"
+		logFields[logger.ERROR] = err
+		logger.ErrorFields(errMsg, logFields)
+
+		return result, 500, fmt.Errorf("%s for %s because of %v", errMsg, workflowID, err)
+	}
+
+	logger.InfoFields("Successfully delete workflow", logFields)
+
+	return result, status, err
+}
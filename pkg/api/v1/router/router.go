@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/jinghzhu/kservice/pkg/api/v1/handler"
 	"github.com/jinghzhu/kservice/pkg/logger"
 
@@ -19,15 +21,63 @@ import (
 const (
 	// router
 	routerV1 = "/api/v1"
+	// namespaced router, the endpoints under it deal with workers in the given namespace
+	routerNamespace = "/namespaces/{ns}"
 	// api endpoint
-	epPostPod      = "/pods"
-	epGetPodStatus = "/pods/{key}/status"
-	epGetPodLogs   = "/pods/{key}/logs"
-	epGetPodInfo   = "/pods/{key}/info"
+	epPostPod           = "/pods"
+	epGetPodStatus      = "/pods/{key}/status"
+	epGetPodLogs        = "/pods/{key}/logs"
+	epGetPodInfo        = "/pods/{key}/info"
+	epDeletePod         = "/pods/{key}"
+	epRerunPod          = "/pods/{key}/rerun"
+	epListPods          = "/pods"
+	epWatchPod          = "/pods/{key}/watch"
+	epWaitPod           = "/pods/{key}/wait"
+	epGetPodEvents      = "/pods/{key}/events"
+	epDiagnosePod       = "/pods/{key}/diagnosis"
+	epExecPod           = "/pods/{key}/exec"
+	epPodFiles          = "/pods/{key}/files"
+	epPodTerminal       = "/pods/{key}/terminal"
+	epPodProxy          = "/pods/{key}/proxy/{port}"
+	epPodProxyPath      = "/pods/{key}/proxy/{port}/{path:.*}"
+	epPostJob           = "/jobs"
+	epGetJobStatus      = "/jobs/{key}/status"
+	epGetJobLogs        = "/jobs/{key}/logs"
+	epDeleteJob         = "/jobs/{key}"
+	epSchedules         = "/schedules"
+	epSchedule          = "/schedules/{key}"
+	epScheduleRuns      = "/schedules/{key}/runs"
+	epPostArray         = "/arrays"
+	epArrayStatus       = "/arrays/{key}/status"
+	epDeleteArray       = "/arrays/{key}"
+	epPostWorkflow      = "/workflows"
+	epWorkflowStatus    = "/workflows/{key}/status"
+	epRetryWorkflowStep = "/workflows/{key}/steps/{step}/retry"
+	epDeleteWorkflow    = "/workflows/{key}"
+	epPostService       = "/services"
+	epServiceStatus     = "/services/{key}/status"
+	epScaleService      = "/services/{key}/scale"
+	epRestartService    = "/services/{key}/restart"
+	epRolloutService    = "/services/{key}/rollout"
+	epDeleteService     = "/services/{key}"
+	epDelayedWorkers    = "/delayed"
+	epDelayedWorker     = "/delayed/{key}"
+	epQueue             = "/queue"
+	epQueuedWorker      = "/queue/{key}"
+	epWorkers           = "/workers"
+	epWorker            = "/workers/{key}"
 )
 
+// proxyMethods are the methods proxied to the worker. CONNECT and TRACE aren't proxied.
+var proxyMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions}
+
 type wrappedHandlerFunc func(context.Context, *http.Request) ([]byte, int, error)
 
+// streamHandlerFunc is the handler which writes the response by itself. It should only return error before
+// anything is written into the response.
+type streamHandlerFunc func(context.Context, http.ResponseWriter, *http.Request) (int, error)
+
 func GetJson(b []byte) (v interface{}, err error) {
 	err = json.Unmarshal(b, &v)
 	return v, err
@@ -66,7 +116,7 @@ func parseRequest(ctx context.Context, r *http.Request) (*httpRequest, error) {
 
 func handlerWrapper(fn wrappedHandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := SetRequestContext(apitypes.ContextRoot)
+		ctx, cancel := SetRequestContext(r.Context())
 		defer cancel()
 		rd, err := parseRequest(ctx, r)
 		body := rd.Body
@@ -96,6 +146,9 @@ func handlerWrapper(fn wrappedHandlerFunc) http.HandlerFunc {
 					"Status":          status,
 				})
 			http.Error(w, errMsg, status)
+		} else if status != http.StatusOK {
+			// Such as 202 for the worker which is accepted but created later.
+			w.WriteHeader(status)
 		}
 		json.NewEncoder(w).Encode(data)
 		logger.InfoFields("Successfully handle with request", logger.Fields{
@@ -106,6 +159,49 @@ func handlerWrapper(fn wrappedHandlerFunc) http.HandlerFunc {
 	}
 }
 
+func streamHandlerWrapper(fn streamHandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The context is canceled once the client goes away, which stops the stream.
+		ctx, cancel := SetRequestContext(r.Context())
+		defer cancel()
+		reqID := fmt.Sprintf("%v", ctx.Value(apitypes.LogCtxID))
+		status, err := fn(ctx, w, r)
+		if err != nil {
+			logger.ErrorFields("Fail to warp stream handler", logger.Fields{
+				apitypes.LogCtxID: reqID,
+				"Request":         r.URL.String(),
+				"Status":          status,
+				"Error":           err,
+			})
+			http.Error(w, err.Error()+" "+reqID, status)
+
+			return
+		}
+		logger.InfoFields("Successfully handle with stream request", logger.Fields{
+			apitypes.LogCtxID: reqID,
+			"Request":         r.URL.String(),
+			"Status":          status,
+		})
+	}
+}
+
+// isLogStream matches the log requests with query parameter follow=true, or which ask for plain text or
+// Server-Sent Events by Accept header or format.
+func isLogStream(r *http.Request, rm *mux.RouteMatch) bool {
+	if follow, err := strconv.ParseBool(r.URL.Query().Get("follow")); err == nil && follow {
+		return true
+	}
+	accept, format := r.Header.Get("Accept"), r.URL.Query().Get("format")
+
+	return strings.HasPrefix(accept, "text/plain") || strings.Contains(accept, "text/event-stream") ||
+		strings.EqualFold(format, "text") || strings.EqualFold(format, "sse")
+}
+
+// isWebSocket matches the requests to upgrade to WebSocket.
+func isWebSocket(r *http.Request, rm *mux.RouteMatch) bool {
+	return websocket.IsWebSocketUpgrade(r)
+}
+
 func DefaultRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 	SetRouterV1(router)
@@ -116,14 +212,121 @@ func DefaultRouter() *mux.Router {
 // v1 api router
 func SetRouterV1(r *mux.Router) {
 	routerV1 := r.PathPrefix(routerV1).Subrouter()
-	routerV1.HandleFunc(epPostPod, handlerWrapper(handler.CreatePod)).Methods(http.MethodPost)
-	routerV1.HandleFunc(epGetPodStatus, handlerWrapper(handler.GetPodStatus)).Methods(http.MethodGet)
-	routerV1.HandleFunc(epGetPodLogs, handlerWrapper(handler.GetPodLog)).Methods(http.MethodGet)
-	routerV1.HandleFunc(epGetPodInfo, handlerWrapper(handler.GetPodInfo)).Methods(http.MethodGet)
+	routerNS := routerV1.PathPrefix(routerNamespace).Subrouter()
+	setPodRouter(routerNS)
+	setPodRouter(routerV1)
+	setJobRouter(routerNS)
+	setJobRouter(routerV1)
+	setScheduleRouter(routerNS)
+	setScheduleRouter(routerV1)
+	setArrayRouter(routerNS)
+	setArrayRouter(routerV1)
+	setWorkflowRouter(routerNS)
+	setWorkflowRouter(routerV1)
+	setServiceRouter(routerNS)
+	setServiceRouter(routerV1)
+	setDelayedRouter(routerNS)
+	setDelayedRouter(routerV1)
+	setQueueRouter(routerNS)
+	setQueueRouter(routerV1)
+	setWorkerRouter(routerV1)
+}
+
+// setJobRouter registers the Job endpoints.
+func setJobRouter(r *mux.Router) {
+	r.HandleFunc(epPostJob, handlerWrapper(handler.CreateJob)).Methods(http.MethodPost)
+	r.HandleFunc(epGetJobStatus, handlerWrapper(handler.GetJobStatus)).Methods(http.MethodGet)
+	r.HandleFunc(epGetJobLogs, handlerWrapper(handler.GetJobLog)).Methods(http.MethodGet)
+	r.HandleFunc(epDeleteJob, handlerWrapper(handler.DeleteJob)).Methods(http.MethodDelete)
+}
+
+// setScheduleRouter registers the schedule endpoints.
+func setScheduleRouter(r *mux.Router) {
+	r.HandleFunc(epSchedules, handlerWrapper(handler.CreateSchedule)).Methods(http.MethodPost)
+	r.HandleFunc(epSchedules, handlerWrapper(handler.ListSchedules)).Methods(http.MethodGet)
+	r.HandleFunc(epSchedule, handlerWrapper(handler.GetSchedule)).Methods(http.MethodGet)
+	r.HandleFunc(epSchedule, handlerWrapper(handler.UpdateSchedule)).Methods(http.MethodPut)
+	r.HandleFunc(epSchedule, handlerWrapper(handler.DeleteSchedule)).Methods(http.MethodDelete)
+	r.HandleFunc(epScheduleRuns, handlerWrapper(handler.ListScheduleRuns)).Methods(http.MethodGet)
+}
+
+// setArrayRouter registers the worker array endpoints.
+func setArrayRouter(r *mux.Router) {
+	r.HandleFunc(epPostArray, handlerWrapper(handler.CreateArray)).Methods(http.MethodPost)
+	r.HandleFunc(epArrayStatus, handlerWrapper(handler.GetArrayStatus)).Methods(http.MethodGet)
+	r.HandleFunc(epDeleteArray, handlerWrapper(handler.CancelArray)).Methods(http.MethodDelete)
+}
+
+// setWorkflowRouter registers the workflow endpoints.
+func setWorkflowRouter(r *mux.Router) {
+	r.HandleFunc(epPostWorkflow, handlerWrapper(handler.CreateWorkflow)).Methods(http.MethodPost)
+	r.HandleFunc(epWorkflowStatus, handlerWrapper(handler.GetWorkflowStatus)).Methods(http.MethodGet)
+	r.HandleFunc(epRetryWorkflowStep, handlerWrapper(handler.RetryWorkflowStep)).Methods(http.MethodPost)
+	r.HandleFunc(epDeleteWorkflow, handlerWrapper(handler.DeleteWorkflow)).Methods(http.MethodDelete)
+}
+
+// setServiceRouter registers the long-running service endpoints.
+func setServiceRouter(r *mux.Router) {
+	r.HandleFunc(epPostService, handlerWrapper(handler.CreateService)).Methods(http.MethodPost)
+	r.HandleFunc(epServiceStatus, handlerWrapper(handler.GetServiceStatus)).Methods(http.MethodGet)
+	r.HandleFunc(epScaleService, handlerWrapper(handler.ScaleService)).Methods(http.MethodPut)
+	r.HandleFunc(epRestartService, handlerWrapper(handler.RestartService)).Methods(http.MethodPost)
+	r.HandleFunc(epRolloutService, handlerWrapper(handler.RolloutService)).Methods(http.MethodPut)
+	r.HandleFunc(epDeleteService, handlerWrapper(handler.DeleteService)).Methods(http.MethodDelete)
+}
+
+// setDelayedRouter registers the delayed worker endpoints. Delayed workers are submitted by POST /pods with runAt.
+func setDelayedRouter(r *mux.Router) {
+	r.HandleFunc(epDelayedWorkers, handlerWrapper(handler.ListDelayedWorkers)).Methods(http.MethodGet)
+	r.HandleFunc(epDelayedWorker, handlerWrapper(handler.GetDelayedWorker)).Methods(http.MethodGet)
+	r.HandleFunc(epDelayedWorker, handlerWrapper(handler.CancelDelayedWorker)).Methods(http.MethodDelete)
+}
+
+// setQueueRouter registers the admission queue endpoints.
+func setQueueRouter(r *mux.Router) {
+	r.HandleFunc(epQueue, handlerWrapper(handler.ListQueue)).Methods(http.MethodGet)
+	r.HandleFunc(epQueuedWorker, handlerWrapper(handler.GetQueuedWorker)).Methods(http.MethodGet)
+	r.HandleFunc(epQueuedWorker, handlerWrapper(handler.ReorderQueuedWorker)).Methods(http.MethodPut)
+	r.HandleFunc(epQueuedWorker, handlerWrapper(handler.DropQueuedWorker)).Methods(http.MethodDelete)
+}
+
+// setWorkerRouter registers the Worker custom resource endpoints. Workers always live in the CRD namespace, so they
+// have no namespaced routes.
+func setWorkerRouter(r *mux.Router) {
+	r.HandleFunc(epWorkers, handlerWrapper(handler.CreateWorker)).Methods(http.MethodPost)
+	r.HandleFunc(epWorkers, handlerWrapper(handler.ListWorkers)).Methods(http.MethodGet)
+	r.HandleFunc(epWorker, handlerWrapper(handler.GetWorker)).Methods(http.MethodGet)
+	r.HandleFunc(epWorker, handlerWrapper(handler.DeleteWorker)).Methods(http.MethodDelete)
+}
+
+// setPodRouter registers the worker endpoints.
+func setPodRouter(r *mux.Router) {
+	r.HandleFunc(epPostPod, handlerWrapper(handler.CreatePod)).Methods(http.MethodPost)
+	r.HandleFunc(epGetPodStatus, handlerWrapper(handler.GetPodStatus)).Methods(http.MethodGet)
+	r.HandleFunc(epGetPodLogs, streamHandlerWrapper(handler.StreamPodLog)).Methods(http.MethodGet).
+		MatcherFunc(isLogStream)
+	r.HandleFunc(epGetPodLogs, handlerWrapper(handler.GetPodLog)).Methods(http.MethodGet)
+	r.HandleFunc(epGetPodInfo, handlerWrapper(handler.GetPodInfo)).Methods(http.MethodGet)
+	r.HandleFunc(epListPods, handlerWrapper(handler.ListPods)).Methods(http.MethodGet)
+	r.HandleFunc(epWatchPod, streamHandlerWrapper(handler.WatchPod)).Methods(http.MethodGet)
+	r.HandleFunc(epWaitPod, handlerWrapper(handler.WaitPod)).Methods(http.MethodGet)
+	r.HandleFunc(epGetPodEvents, handlerWrapper(handler.GetPodEvents)).Methods(http.MethodGet)
+	r.HandleFunc(epDiagnosePod, handlerWrapper(handler.DiagnosePod)).Methods(http.MethodGet)
+	r.HandleFunc(epExecPod, handlerWrapper(handler.ExecPod)).Methods(http.MethodPost)
+	r.HandleFunc(epExecPod, streamHandlerWrapper(handler.ExecPodWebSocket)).Methods(http.MethodGet).
+		MatcherFunc(isWebSocket)
+	r.HandleFunc(epPodFiles, streamHandlerWrapper(handler.DownloadPodFiles)).Methods(http.MethodGet)
+	r.HandleFunc(epPodFiles, streamHandlerWrapper(handler.UploadPodFiles)).Methods(http.MethodPut)
+	r.HandleFunc(epPodTerminal, streamHandlerWrapper(handler.AttachPodTerminal)).Methods(http.MethodGet).
+		MatcherFunc(isWebSocket)
+	r.HandleFunc(epPodProxy, streamHandlerWrapper(handler.ProxyPod)).Methods(proxyMethods...)
+	r.HandleFunc(epPodProxyPath, streamHandlerWrapper(handler.ProxyPod)).Methods(proxyMethods...)
+	r.HandleFunc(epRerunPod, handlerWrapper(handler.RerunPod)).Methods(http.MethodPost)
+	r.HandleFunc(epDeletePod, handlerWrapper(handler.DeletePod)).Methods(http.MethodDelete)
 }
 
 func SetRequestContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	id, _ := uuid.NewRandom()
-	ctx1, cancel := context.WithCancel(context.Background())
+	ctx1, cancel := context.WithCancel(ctx)
 	return context.WithValue(ctx1, apitypes.LogCtxID, id), cancel
 }
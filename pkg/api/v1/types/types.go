@@ -2,6 +2,10 @@ package types
 
 import (
 	"time"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -11,6 +15,12 @@ const (
 
 	// EnvUser is used to set the system variable for container.
 	EnvUser string = "USER"
+	// EnvArrayID, EnvArrayIndex and EnvArraySize are set for the workers of array.
+	EnvArrayID    string = "KSERVICE_ARRAY_ID"
+	EnvArrayIndex string = "KSERVICE_ARRAY_INDEX"
+	EnvArraySize  string = "KSERVICE_ARRAY_SIZE"
+	// MaxArraySize is the maximum number of workers in one array.
+	MaxArraySize int = 1000
 )
 
 type Resource struct {
@@ -39,24 +49,379 @@ type UserInfo struct {
 	GroupID  []int64 `json:"gid"`
 }
 
+// Logs is the log of worker. Truncated is true if the log is cut at limitBytes, which is 10MiB at most, and the
+// rest of it can be streamed in plain text.
 type Logs struct {
-	Log string
+	Log       string
+	Truncated bool `json:"truncated,omitempty"`
 }
 
+// Mount is the volume mounted into worker container. Type is NFS by default. Server and Share are of NFS mount.
+// Source is the name of secret, ConfigMap or PersistentVolumeClaim. Medium and SizeLimit are of emptyDir mount,
+// whose Medium is empty, Memory, HugePages or HugePages-<size>.
 type Mount struct {
-	Name      string `string:"name,omitempty"`
-	Type      string `string:"type,omitempty"`
-	Share     string `string:"share"`
-	Server    string `string:"server"`
-	MountPath string `string:"mountpath"`
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Share     string `json:"share,omitempty"`
+	Server    string `json:"server,omitempty"`
+	MountPath string `json:"mountpath"`
+	Source    string `json:"source,omitempty"`
+	Medium    string `json:"medium,omitempty"`
+	SizeLimit string `json:"sizelimit,omitempty"`
+	ReadOnly  bool   `json:"readonly,omitempty"`
+	SubPath   string `json:"subpath,omitempty"`
 }
 
 type ExitCode *int32
 
+// WorkerStatus is the status of worker. The top-level State, Reason, Message and ExitCode come from the worker
+// container while the details of every container are in Containers and InitContainers.
 type WorkerStatus struct {
-	Status   string   `json:"status"`
-	Reason   string   `json:"reason"`
-	State    string   `json:"state"`
-	Message  string   `json:"msg"`
-	ExitCode ExitCode `json:"exitCode"`
+	Status         string            `json:"status"`
+	Reason         string            `json:"reason"`
+	State          string            `json:"state"`
+	Message        string            `json:"msg"`
+	ExitCode       ExitCode          `json:"exitCode"`
+	NodeName       string            `json:"nodeName,omitempty"`
+	PodIP          string            `json:"podIP,omitempty"`
+	StartTime      *time.Time        `json:"startTime,omitempty"`
+	FinishTime     *time.Time        `json:"finishTime,omitempty"`
+	RestartCount   int32             `json:"restartCount"`
+	OOMKilled      bool              `json:"oomKilled"`
+	Evicted        bool              `json:"evicted"`
+	Conditions     []PodCondition    `json:"conditions,omitempty"`
+	InitContainers []ContainerStatus `json:"initContainers,omitempty"`
+	Containers     []ContainerStatus `json:"containers,omitempty"`
+	// Attempt and Attempts are the current attempt number and the history of all attempts of the worker with retry
+	// policy, in which case the other fields are of the latest attempt.
+	Attempt  int32           `json:"attempt,omitempty"`
+	Attempts []WorkerAttempt `json:"attempts,omitempty"`
+	// PreemptedBy is the ID of worker which preempts this one. The namespace is prefixed if it's in another one.
+	PreemptedBy string `json:"preemptedBy,omitempty"`
+	// TimedOut is true if waiting for the worker state times out, in which case the status is the current one.
+	TimedOut bool `json:"timedOut,omitempty"`
+}
+
+// WorkerAttempt is one attempt of the worker with retry policy.
+type WorkerAttempt struct {
+	Attempt    int32      `json:"attempt"`
+	Id         string     `json:"id"`
+	Status     string     `json:"status"`
+	State      string     `json:"state"`
+	Reason     string     `json:"reason,omitempty"`
+	ExitCode   ExitCode   `json:"exitCode,omitempty"`
+	StartTime  *time.Time `json:"startTime,omitempty"`
+	FinishTime *time.Time `json:"finishTime,omitempty"`
+}
+
+// ContainerStatus is the status of one container of worker.
+type ContainerStatus struct {
+	Name         string     `json:"name"`
+	State        string     `json:"state"`
+	Reason       string     `json:"reason,omitempty"`
+	Message      string     `json:"msg,omitempty"`
+	ExitCode     ExitCode   `json:"exitCode,omitempty"`
+	Ready        bool       `json:"ready"`
+	RestartCount int32      `json:"restartCount"`
+	Image        string     `json:"image"`
+	ImageID      string     `json:"imageID,omitempty"`
+	StartTime    *time.Time `json:"startTime,omitempty"`
+	FinishTime   *time.Time `json:"finishTime,omitempty"`
+	OOMKilled    bool       `json:"oomKilled"`
+}
+
+// PodCondition is the condition of worker Pod, such as PodScheduled and Ready.
+type PodCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"msg,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// WorkerSummary is the brief information of a worker in the worker list.
+type WorkerSummary struct {
+	Id           string    `json:"id"`
+	Namespace    string    `json:"namespace"`
+	Image        string    `json:"image"`
+	CreationTime time.Time `json:"creationTime"`
+	WorkerStatus
+}
+
+// WorkerList is a page of workers. Continue is the token to retrieve the next page and it's empty on the last page.
+type WorkerList struct {
+	Items    []WorkerSummary `json:"items"`
+	Continue string          `json:"continue,omitempty"`
+}
+
+// IsTerminated returns true if the worker won't change its status any more.
+func (ws *WorkerStatus) IsTerminated() bool {
+	return ws.State == apitypes.WorkerStatusTerminated ||
+		ws.Status == string(corev1.PodSucceeded) || ws.Status == string(corev1.PodFailed)
+}
+
+// WorkerEvent is the Kubernetes event about the worker.
+type WorkerEvent struct {
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"msg"`
+	Count     int32     `json:"count"`
+	Source    string    `json:"source,omitempty"`
+	FirstTime time.Time `json:"firstTime"`
+	LastTime  time.Time `json:"lastTime"`
+}
+
+// DiagnosisItem is one problem found for the worker and the suggested fix.
+type DiagnosisItem struct {
+	Reason     string `json:"reason"`
+	Message    string `json:"msg"`
+	Suggestion string `json:"suggestion"`
+}
+
+// WorkerDiagnosis explains why the worker is in its current state.
+type WorkerDiagnosis struct {
+	WorkerStatus WorkerStatus    `json:"workerStatus"`
+	Summary      string          `json:"summary"`
+	Problems     []DiagnosisItem `json:"problems"`
+	Events       []WorkerEvent   `json:"events"`
+}
+
+// ExecRequest is the command to run inside the worker container.
+type ExecRequest struct {
+	Cmd       []string `json:"cmd"`
+	Container string   `json:"container,omitempty"`
+	Stdin     string   `json:"stdin,omitempty"`
+}
+
+// ExecResult is the output of command run inside the worker container. Truncated is true if the output exceeds
+// the size limit.
+type ExecResult struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exitCode"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// StreamMessage is the message exchanged over WebSocket. The server sends stdout, stderr, exit and error
+// messages while the client sends stdin and resize messages. Cols and Rows are the terminal size of resize.
+type StreamMessage struct {
+	Type     string `json:"type"`
+	Data     string `json:"data,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+	Cols     uint16 `json:"cols,omitempty"`
+	Rows     uint16 `json:"rows,omitempty"`
+}
+
+// UploadResult is the result of uploading files into the worker.
+type UploadResult struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// JobCondition is the condition of Job, such as Complete and Failed.
+type JobCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"msg,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// JobStatus is the status of Job aggregated over its workers.
+type JobStatus struct {
+	Id             string          `json:"id"`
+	Status         string          `json:"status"`
+	Active         int32           `json:"active"`
+	Succeeded      int32           `json:"succeeded"`
+	Failed         int32           `json:"failed"`
+	CreationTime   time.Time       `json:"creationTime"`
+	StartTime      *time.Time      `json:"startTime,omitempty"`
+	CompletionTime *time.Time      `json:"completionTime,omitempty"`
+	Conditions     []JobCondition  `json:"conditions,omitempty"`
+	Workers        []WorkerSummary `json:"workers"`
+}
+
+// WorkerLog is the log of one worker.
+type WorkerLog struct {
+	Id  string `json:"id"`
+	Log string `json:"log"`
+	// Truncated is true if the log is cut at limitBytes.
+	Truncated bool `json:"truncated,omitempty"`
+	// Error is the reason why the log can't be retrieved.
+	Error string `json:"error,omitempty"`
+}
+
+// JobLogs is the logs of all workers of Job.
+type JobLogs struct {
+	Id   string      `json:"id"`
+	Logs []WorkerLog `json:"logs"`
+}
+
+// ScheduleStatus is the spec and status of the scheduled worker.
+type ScheduleStatus struct {
+	Id                         string     `json:"id"`
+	Namespace                  string     `json:"namespace"`
+	Image                      string     `json:"image"`
+	Schedule                   string     `json:"schedule"`
+	ConcurrencyPolicy          string     `json:"concurrencyPolicy"`
+	Suspend                    bool       `json:"suspend"`
+	StartingDeadlineSeconds    *int64     `json:"startingDeadlineSeconds,omitempty"`
+	SuccessfulJobsHistoryLimit *int32     `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32     `json:"failedJobsHistoryLimit,omitempty"`
+	CreationTime               time.Time  `json:"creationTime"`
+	LastScheduleTime           *time.Time `json:"lastScheduleTime,omitempty"`
+	// Active is the names of Jobs which are running.
+	Active []string `json:"active"`
+}
+
+// ScheduleList is a page of scheduled workers. Continue is the token to get the next page.
+type ScheduleList struct {
+	Items    []ScheduleStatus `json:"items"`
+	Continue string           `json:"continue,omitempty"`
+}
+
+// ScheduleRuns is the past and running Jobs of the scheduled worker, the latest first.
+type ScheduleRuns struct {
+	Id   string      `json:"id"`
+	Runs []JobStatus `json:"runs"`
+}
+
+// Worker is the Worker custom resource. Its spec is the same as WorkerPod and its status is written back by the
+// controller.
+type Worker struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              WorkerPod             `json:"spec"`
+	Status            *WorkerResourceStatus `json:"status,omitempty"`
+}
+
+// WorkerResourceStatus is the status of Worker custom resource. PodName and PodNamespace locate the Pod which runs
+// the Worker.
+type WorkerResourceStatus struct {
+	WorkerStatus       `json:",inline"`
+	PodName            string `json:"podName,omitempty"`
+	PodNamespace       string `json:"podNamespace,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// WorkerResourceList is a page of Worker custom resources. Continue is the token to get the next page.
+type WorkerResourceList struct {
+	Items    []Worker `json:"items"`
+	Continue string   `json:"continue,omitempty"`
+}
+
+// ArrayDetails is the ID of worker array and the workers it deals with.
+type ArrayDetails struct {
+	Id      string   `json:"id"`
+	Size    int      `json:"size"`
+	Workers []string `json:"workers"`
+}
+
+// ArrayElement is the status of one worker of array.
+type ArrayElement struct {
+	Index  int               `json:"index"`
+	Params map[string]string `json:"params,omitempty"`
+	WorkerSummary
+}
+
+// ArrayStatus is the progress of worker array and the status of every worker, ordered by index.
+type ArrayStatus struct {
+	Id        string         `json:"id"`
+	Size      int            `json:"size"`
+	Pending   int            `json:"pending"`
+	Running   int            `json:"running"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Elements  []ArrayElement `json:"elements"`
+}
+
+// WorkflowStatus is the status of workflow and every step, in the order of steps in spec.
+type WorkflowStatus struct {
+	Id           string       `json:"id"`
+	Namespace    string       `json:"namespace"`
+	Phase        string       `json:"phase"`
+	CreationTime time.Time    `json:"creationTime"`
+	FinishTime   *time.Time   `json:"finishTime,omitempty"`
+	Steps        []StepStatus `json:"steps"`
+}
+
+// StepStatus is the status of workflow step. Worker is the Pod of the latest attempt.
+type StepStatus struct {
+	Name       string            `json:"name"`
+	Phase      string            `json:"phase"`
+	Attempt    int               `json:"attempt"`
+	Worker     string            `json:"worker,omitempty"`
+	ExitCode   ExitCode          `json:"exitCode,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Message    string            `json:"msg,omitempty"`
+	Outputs    map[string]string `json:"outputs,omitempty"`
+	StartTime  *time.Time        `json:"startTime,omitempty"`
+	FinishTime *time.Time        `json:"finishTime,omitempty"`
+}
+
+// ServiceCondition is the condition of service Deployment, such as Available and Progressing.
+type ServiceCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"msg,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// ServiceStatus is the rollout and readiness of service. Ready is true if all replicas are updated and ready.
+type ServiceStatus struct {
+	Id                string             `json:"id"`
+	Namespace         string             `json:"namespace"`
+	Image             string             `json:"image"`
+	ClusterIP         string             `json:"clusterIP,omitempty"`
+	Ports             []int32            `json:"ports,omitempty"`
+	Replicas          int32              `json:"replicas"`
+	ReadyReplicas     int32              `json:"readyReplicas"`
+	UpdatedReplicas   int32              `json:"updatedReplicas"`
+	AvailableReplicas int32              `json:"availableReplicas"`
+	Ready             bool               `json:"ready"`
+	Conditions        []ServiceCondition `json:"conditions,omitempty"`
+	Workers           []WorkerSummary    `json:"workers"`
+}
+
+// DelayedWorker is the worker which will be created at RunAt. Id is the name of its Pod.
+type DelayedWorker struct {
+	Id           string    `json:"id"`
+	Namespace    string    `json:"namespace"`
+	Image        string    `json:"image"`
+	RunAt        time.Time `json:"runAt"`
+	Phase        string    `json:"phase"`
+	Message      string    `json:"msg,omitempty"`
+	CreationTime time.Time `json:"creationTime"`
+}
+
+// DelayedWorkerList is the delayed workers ordered by RunAt.
+type DelayedWorkerList struct {
+	Items []DelayedWorker `json:"items"`
+}
+
+// QueuedWorker is the worker held in admission queue until the concurrency limits allow it to be created. Id is
+// the name of its Pod and Position starts from 1 across all namespaces.
+type QueuedWorker struct {
+	Id          string    `json:"id"`
+	Namespace   string    `json:"namespace"`
+	User        string    `json:"user,omitempty"`
+	Image       string    `json:"image"`
+	Priority    int32     `json:"priority"`
+	Position    int       `json:"position"`
+	State       string    `json:"state"`
+	Message     string    `json:"msg,omitempty"`
+	EnqueueTime time.Time `json:"enqueueTime"`
+}
+
+// QueuedWorkerList is the queued workers in dispatch order.
+type QueuedWorkerList struct {
+	Items []QueuedWorker `json:"items"`
+}
+
+// QueueUpdate reorders the queued worker by its priority or by moving it to the front or back of the workers with
+// the same priority.
+type QueueUpdate struct {
+	Priority *int32 `json:"priority,omitempty"`
+	Position string `json:"position,omitempty"`
 }
@@ -1,11 +1,16 @@
 package types
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jinghzhu/kservice/pkg/config"
 	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
@@ -20,7 +25,7 @@ type WorkerPod struct {
 	// Cmd is the command to run. If not set dockerfile.Entrypoint is used.
 	Cmd  []string `json:"cmd"`
 	Name string   `json:"-"`
-	// Mounts is the array of NFS mount points.
+	// Mounts is the array of NFS, secret, ConfigMap, PersistentVolumeClaim and emptyDir mount points.
 	Mounts           []Mount           `json:"mounts,omitempty"`
 	Env              map[string]string `json:"env,omitempty"`
 	ResourceLimits   *Resource         `json:"limit,omitempty"`
@@ -33,6 +38,22 @@ type WorkerPod struct {
 	Prefix           string            `json:"prefix,omitempty"`
 	Annotations      map[string]string `json:"annotations,omitempty"`
 	Labels           map[string]string `json:"labels,omitempty"`
+	// RunAt delays the creation of worker to the time. NotBefore is its alias. They're only supported by POST /pods
+	// and rejected by the others.
+	RunAt     *time.Time `json:"runAt,omitempty"`
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+	// RetryPolicy creates a new Pod for every attempt of failed worker. It's only supported by POST /pods and
+	// rejected by the others.
+	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// MaxRuntime is the seconds the worker can be active, which is the activeDeadlineSeconds of Pod. MaxPending is
+	// the seconds the worker can stay in Pending, such as being unschedulable or pulling image. The worker exceeding
+	// either of them is failed with reason TimedOut.
+	MaxRuntime int64 `json:"maxRuntime,omitempty"`
+	MaxPending int64 `json:"maxPending,omitempty"`
+	// Priority orders the workers in admission queue. The worker with higher priority is created first and the ones
+	// with the same priority are created in submission order. It's also mapped onto the PriorityClass configured by
+	// server, so the worker may preempt the ones with lower priority when it can't be scheduled.
+	Priority int32 `json:"priority,omitempty"`
 }
 
 // DefaultWorkerPod created the WorkerPod with default value.
@@ -60,28 +81,93 @@ func (wp *WorkerPod) TranslateEnv() (envVar []corev1.EnvVar) {
 	return
 }
 
-// TranslateMounts translate WorkerPod.Mounts to corev1.VolumeMount and corev1.Volume.
-func (wp *WorkerPod) TranslateMounts() ([]corev1.VolumeMount, []corev1.Volume) {
+// TranslateMounts translate WorkerPod.Mounts to corev1.VolumeMount and corev1.Volume. The volume is named after
+// the mount, or generated if the mount has no name.
+func (wp *WorkerPod) TranslateMounts() ([]corev1.VolumeMount, []corev1.Volume, error) {
 	mounts := wp.Mounts
 	vols := []corev1.Volume{}
 	volMounts := []corev1.VolumeMount{}
-	for _, mount := range mounts {
-		name := strconv.FormatInt(time.Now().UnixNano(), 10)
+	for i, mount := range mounts {
+		name := mount.Name
+		if name == "" {
+			name = strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.Itoa(i)
+		}
+		source, err := mount.translateVolumeSource()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid mount %s: %v", mount.MountPath, err)
+		}
 		vols = append(vols, corev1.Volume{
-			Name: name,
-			VolumeSource: corev1.VolumeSource{
-				NFS: &corev1.NFSVolumeSource{
-					Server: mount.Server,
-					Path:   mount.Share,
-				},
-			},
+			Name:         name,
+			VolumeSource: source,
 		})
 		volMounts = append(volMounts, corev1.VolumeMount{
 			Name:      name,
 			MountPath: mount.MountPath,
+			ReadOnly:  mount.ReadOnly,
+			SubPath:   mount.SubPath,
 		})
 	}
-	return volMounts, vols
+	return volMounts, vols, nil
+}
+
+// translateVolumeSource translates the Mount to corev1.VolumeSource by its type.
+func (mount *Mount) translateVolumeSource() (source corev1.VolumeSource, err error) {
+	mountType := mount.Type
+	if mountType == "" {
+		mountType = apitypes.MountTypeNFS
+	}
+	if !strings.EqualFold(mountType, apitypes.MountTypeEmptyDir) && (mount.Medium != "" || mount.SizeLimit != "") {
+		return source, errors.New("medium and sizelimit are only for emptyDir")
+	}
+	switch {
+	case strings.EqualFold(mountType, apitypes.MountTypeNFS):
+		source.NFS = &corev1.NFSVolumeSource{
+			Server: mount.Server,
+			Path:   mount.Share,
+		}
+	case strings.EqualFold(mountType, apitypes.MountTypeKubeSecret):
+		source.Secret = &corev1.SecretVolumeSource{
+			SecretName: mount.Source,
+		}
+	case strings.EqualFold(mountType, apitypes.MountTypeConfigMap):
+		source.ConfigMap = &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: mount.Source},
+		}
+	case strings.EqualFold(mountType, apitypes.MountTypePVC):
+		source.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: mount.Source,
+			ReadOnly:  mount.ReadOnly,
+		}
+	case strings.EqualFold(mountType, apitypes.MountTypeEmptyDir):
+		source.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		switch medium := corev1.StorageMedium(mount.Medium); {
+		case medium == corev1.StorageMediumDefault, medium == corev1.StorageMediumMemory,
+			medium == corev1.StorageMediumHugePages:
+			source.EmptyDir.Medium = medium
+		case strings.HasPrefix(mount.Medium, string(corev1.StorageMediumHugePagesPrefix)):
+			pageSize := strings.TrimPrefix(mount.Medium, string(corev1.StorageMediumHugePagesPrefix))
+			if _, err := resource.ParseQuantity(pageSize); err != nil {
+				return source, fmt.Errorf("invalid page size of emptyDir medium %s: %v", mount.Medium, err)
+			}
+			source.EmptyDir.Medium = medium
+		default:
+			return source, fmt.Errorf("unknown emptyDir medium %s", mount.Medium)
+		}
+		if mount.SizeLimit != "" {
+			sizeLimit, err := resource.ParseQuantity(mount.SizeLimit)
+			if err != nil {
+				return source, fmt.Errorf("invalid sizelimit %s: %v", mount.SizeLimit, err)
+			}
+			source.EmptyDir.SizeLimit = &sizeLimit
+		}
+	default:
+		return source, fmt.Errorf("unknown mount type %s", mount.Type)
+	}
+	if source.NFS == nil && source.EmptyDir == nil && mount.Source == "" {
+		return source, fmt.Errorf("source is mandatory for %s mount", mountType)
+	}
+
+	return source, nil
 }
 
 // TranslateResourceRequests Translate WorkerPod.ResourceRequests to corev1.ResourceList.
@@ -127,3 +213,110 @@ func translateResource(workerResource Resource) (corev1.ResourceList, error) {
 	}
 	return kubeResource, err
 }
+
+// WorkerJob is to represent the Job spec. The Job runs the WorkerPod with retry and deadline.
+type WorkerJob struct {
+	WorkerPod
+	// BackoffLimit is the number of retries before the Job is marked as failed.
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds is the duration in seconds the Job may be active before it's terminated.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	Completions           *int32 `json:"completions,omitempty"`
+	Parallelism           *int32 `json:"parallelism,omitempty"`
+}
+
+// WorkerSchedule is to represent the CronJob spec. The CronJob runs the WorkerJob on the cron schedule.
+type WorkerSchedule struct {
+	WorkerJob
+	// Schedule is the cron expression, such as "0 * * * *". It's in the time zone of kube-controller-manager since
+	// batch/v1beta1 CronJob has no time zone.
+	Schedule string `json:"schedule"`
+	// ConcurrencyPolicy is one of Allow, Forbid and Replace. Allow is the default.
+	ConcurrencyPolicy          string `json:"concurrencyPolicy,omitempty"`
+	Suspend                    *bool  `json:"suspend,omitempty"`
+	StartingDeadlineSeconds    *int64 `json:"startingDeadlineSeconds,omitempty"`
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+	FailedJobsHistoryLimit     *int32 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// WorkerArray is to represent the array of workers with the same WorkerPod spec. The array is either an index range
+// or a parameter matrix. Every worker gets its index, and its parameter values of matrix, as environment variables.
+type WorkerArray struct {
+	WorkerPod
+	IndexRange *IndexRange `json:"indexRange,omitempty"`
+	// Matrix is the parameter name and its values. A worker is created for every combination of values.
+	Matrix map[string][]string `json:"matrix,omitempty"`
+}
+
+// IndexRange is the range of array index from Start to End inclusive.
+type IndexRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Workflow is to represent the DAG of steps. A step runs after all of its dependencies are finished and its run
+// condition is met.
+type Workflow struct {
+	// Namespace is where the workflow and its step workers are. The namespace of step worker is ignored.
+	Namespace string         `json:"namespace,omitempty"`
+	Steps     []WorkflowStep `json:"steps"`
+}
+
+// WorkflowStep is one step of Workflow. The Cmd and Env values of Worker may refer to the results of dependencies
+// by {{steps.<name>.exitCode}} and {{steps.<name>.outputs.<key>}}. The outputs of step are the JSON object of
+// strings which its worker writes to /dev/termination-log.
+type WorkflowStep struct {
+	Name         string   `json:"name"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	// When is one of Succeeded, Failed and Always. Succeeded, the default, runs the step if all dependencies
+	// succeed. Failed runs the step if any dependency fails.
+	When string `json:"when,omitempty"`
+	// ExitCodes further requires the exit code of the dependency to be one of the values.
+	ExitCodes map[string][]int32 `json:"exitCodes,omitempty"`
+	Worker    WorkerPod          `json:"worker"`
+}
+
+// WorkerService is to represent the long-running service. The WorkerPod runs in Deployment and the Ports are
+// exposed by Service.
+type WorkerService struct {
+	WorkerPod
+	Replicas *int32  `json:"replicas,omitempty"`
+	Ports    []int32 `json:"ports,omitempty"`
+	// ReadinessPath is the HTTP path on the first port to probe whether the worker is ready.
+	ReadinessPath string `json:"readinessPath,omitempty"`
+}
+
+// ServiceScale is the number of replicas of service.
+type ServiceScale struct {
+	Replicas *int32 `json:"replicas"`
+}
+
+// ServiceRollout is the new image of service. The current image is kept if Image is empty.
+type ServiceRollout struct {
+	Image        string `json:"image,omitempty"`
+	ImageVersion string `json:"imageversion"`
+}
+
+// WorkerRerun is the overrides of worker to rerun. Env is merged into the env of worker.
+type WorkerRerun struct {
+	ImageVersion     string            `json:"imageversion,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+	ResourceLimits   *Resource         `json:"limit,omitempty"`
+	ResourceRequests *Resource         `json:"resource,omitempty"`
+}
+
+// RetryPolicy is the policy to retry the failed worker. The worker is retried if its exit code is in ExitCodes or
+// its reason is in Reasons, such as OOMKilled, Evicted and ImagePullTimeout. Any failure is retried if both are
+// empty. The delay before the next attempt starts from BackoffSeconds and doubles every attempt up to
+// MaxBackoffSeconds.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts       int32    `json:"maxAttempts"`
+	ExitCodes         []int32  `json:"exitCodes,omitempty"`
+	Reasons           []string `json:"reasons,omitempty"`
+	BackoffSeconds    int32    `json:"backoffSeconds,omitempty"`
+	MaxBackoffSeconds int32    `json:"maxBackoffSeconds,omitempty"`
+	// ImagePullTimeoutSeconds fails the attempt which can't pull image in time with reason ImagePullTimeout. The
+	// attempt waits for the image forever if it's not set.
+	ImagePullTimeoutSeconds int32 `json:"imagePullTimeoutSeconds,omitempty"`
+}
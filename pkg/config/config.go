@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 func init() {
@@ -19,11 +22,20 @@ func initConfig() {
 		config.CRDNamespace = defaultCRDNamespace
 	}
 
-	config.CRDNamespace = os.Getenv("KSERVICE_WORKER_NAMESPACE")
+	config.WorkerNamespace = os.Getenv("KSERVICE_WORKER_NAMESPACE")
 	if config.WorkerNamespace == "" {
 		config.WorkerNamespace = defaultWorkerNamespace
 	}
 
+	// The worker namespace is always allowed. Other namespaces are separated by comma.
+	config.AllowedNamespaces = []string{config.WorkerNamespace}
+	for _, ns := range strings.Split(os.Getenv("KSERVICE_ALLOWED_NAMESPACES"), ",") {
+		ns = strings.TrimSpace(ns)
+		if ns != "" && !config.IsNamespaceAllowed(ns) {
+			config.AllowedNamespaces = append(config.AllowedNamespaces, ns)
+		}
+	}
+
 	config.KubeContext = os.Getenv("KSERVICE_KUBECONTEXT")
 	if config.KubeContext == "" {
 		config.KubeContext = defaultKubeContext
@@ -41,9 +53,91 @@ func initConfig() {
 	if config.Kubeconfig == "" {
 		config.Kubeconfig = defaultKubeconfig
 	}
+
+	config.WorkerControllerEnabled, _ = strconv.ParseBool(os.Getenv("KSERVICE_WORKER_CONTROLLER"))
+
+	config.MaxWorkers, _ = strconv.Atoi(os.Getenv("KSERVICE_MAX_WORKERS"))
+	config.MaxWorkersPerNamespace, _ = strconv.Atoi(os.Getenv("KSERVICE_MAX_WORKERS_PER_NAMESPACE"))
+	config.MaxWorkersPerUser, _ = strconv.Atoi(os.Getenv("KSERVICE_MAX_WORKERS_PER_USER"))
+
+	config.PriorityClasses = parsePriorityClasses(os.Getenv("KSERVICE_PRIORITY_CLASSES"))
+}
+
+// parsePriorityClasses parses the PriorityClasses separated by comma. Every PriorityClass is in the format of
+// name:minPriority or name:minPriority:user1|user2. The invalid ones are ignored.
+func parsePriorityClasses(value string) []PriorityClass {
+	classes := []PriorityClass{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			continue
+		}
+		minPriority, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil {
+			continue
+		}
+		class := PriorityClass{Name: parts[0], MinPriority: int32(minPriority)}
+		if len(parts) == 3 {
+			for _, user := range strings.Split(parts[2], "|") {
+				if user = strings.TrimSpace(user); user != "" {
+					class.Users = append(class.Users, user)
+				}
+			}
+		}
+		classes = append(classes, class)
+	}
+	sort.SliceStable(classes, func(i, j int) bool {
+		return classes[i].MinPriority > classes[j].MinPriority
+	})
+
+	return classes
 }
 
 // GetConfig returns a pointer to the current config.
 func GetConfig() *Config {
 	return config
 }
+
+// IsQueueEnabled returns true if any limit of active workers is set, in which case the workers are created through
+// admission queue.
+func (c *Config) IsQueueEnabled() bool {
+	return c.MaxWorkers > 0 || c.MaxWorkersPerNamespace > 0 || c.MaxWorkersPerUser > 0
+}
+
+// PriorityClassOf returns the PriorityClass which the priority is mapped onto, which is the one with the highest
+// MinPriority not above it. It returns nil if there's no such PriorityClass.
+func (c *Config) PriorityClassOf(priority int32) *PriorityClass {
+	for i := range c.PriorityClasses {
+		if priority >= c.PriorityClasses[i].MinPriority {
+			return &c.PriorityClasses[i]
+		}
+	}
+
+	return nil
+}
+
+// IsAllowed returns true if the user can use the PriorityClass. The user is the one claimed by the caller, so it's
+// only advisory.
+func (p *PriorityClass) IsAllowed(user string) bool {
+	if len(p.Users) == 0 {
+		return true
+	}
+	for _, u := range p.Users {
+		if u == user {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsNamespaceAllowed returns true if kservice is allowed to deal with workers in the namespace.
+func (c *Config) IsNamespaceAllowed(namespace string) bool {
+	for _, ns := range c.AllowedNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
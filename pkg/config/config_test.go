@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePriorityClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		classes []PriorityClass
+	}{
+		{
+			name:    "empty",
+			value:   "",
+			classes: []PriorityClass{},
+		},
+		{
+			name:  "sorted by min priority",
+			value: "low:0, high:100,medium:50",
+			classes: []PriorityClass{
+				{Name: "high", MinPriority: 100},
+				{Name: "medium", MinPriority: 50},
+				{Name: "low", MinPriority: 0},
+			},
+		},
+		{
+			name:  "users",
+			value: "urgent:1000:alice| bob ||,normal:0",
+			classes: []PriorityClass{
+				{Name: "urgent", MinPriority: 1000, Users: []string{"alice", "bob"}},
+				{Name: "normal", MinPriority: 0},
+			},
+		},
+		{
+			name:  "negative min priority",
+			value: "batch:-10",
+			classes: []PriorityClass{
+				{Name: "batch", MinPriority: -10},
+			},
+		},
+		{
+			name:  "invalid ones are ignored",
+			value: "noprio,:10,bad:x,overflow:4294967296,extra:1:a:b,valid:5",
+			classes: []PriorityClass{
+				{Name: "valid", MinPriority: 5},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if classes := parsePriorityClasses(test.value); !reflect.DeepEqual(classes, test.classes) {
+				t.Errorf("parsePriorityClasses(%q) = %v, want %v", test.value, classes, test.classes)
+			}
+		})
+	}
+}
+
+func TestPriorityClassOf(t *testing.T) {
+	c := &Config{PriorityClasses: parsePriorityClasses("low:0,high:100:alice")}
+	tests := []struct {
+		priority int32
+		class    string
+	}{
+		{priority: -1},
+		{priority: 0, class: "low"},
+		{priority: 99, class: "low"},
+		{priority: 100, class: "high"},
+		{priority: 1000, class: "high"},
+	}
+	for _, test := range tests {
+		class := c.PriorityClassOf(test.priority)
+		if (class == nil && test.class != "") || (class != nil && class.Name != test.class) {
+			t.Errorf("PriorityClassOf(%d) = %v, want %s", test.priority, class, test.class)
+		}
+	}
+	if high := c.PriorityClassOf(100); !high.IsAllowed("alice") || high.IsAllowed("bob") {
+		t.Errorf("PriorityClass %s should be allowed only for alice", high.Name)
+	}
+	if low := c.PriorityClassOf(0); !low.IsAllowed("bob") {
+		t.Errorf("PriorityClass %s should be allowed for everyone", low.Name)
+	}
+}
@@ -29,7 +29,10 @@ type Config struct {
 	// CRDNamespace is the namespace where kservice deals with CRD.
 	CRDNamespace string `json:"crdNamespace"`
 	// WorkerNamespace is the namespace where kservice deals with Pod.
-	WorkerNamespace     string              `json:"workerNamespace"`
+	WorkerNamespace string `json:"workerNamespace"`
+	// AllowedNamespaces are the namespaces where workers can be created and looked up. It always includes
+	// WorkerNamespace.
+	AllowedNamespaces   []string            `json:"allowedNamespaces"`
 	ListenPort          string              `json:"port"`
 	ListenAddress       string              `json:"address"`
 	KubeContext         string              `json:"kubeContest"`
@@ -38,4 +41,27 @@ type Config struct {
 	CRDNamePrefix       string              `json:"crdNamePrefix"`
 	LoggingLocalOptions []*LoggingLocalOpts `json:"localLogOpts"`
 	Kubeconfig          string              `json:"kubeconfig"`
+	// WorkerControllerEnabled is true if kservice runs the controller of Worker custom resource.
+	WorkerControllerEnabled bool `json:"workerControllerEnabled"`
+	// MaxWorkers, MaxWorkersPerNamespace and MaxWorkersPerUser are the limits of active workers in all allowed
+	// namespaces, in one namespace and of one user. The worker exceeding them waits in admission queue. 0 means no
+	// limit and the queue is disabled if there's no limit. The limits are soft since every replica of kservice
+	// dispatches on its own, so they may be exceeded by up to the number of replicas.
+	MaxWorkers             int `json:"maxWorkers"`
+	MaxWorkersPerNamespace int `json:"maxWorkersPerNamespace"`
+	MaxWorkersPerUser      int `json:"maxWorkersPerUser"`
+	// PriorityClasses are the PriorityClasses which worker priorities are mapped onto, ordered by MinPriority from
+	// high to low.
+	PriorityClasses []PriorityClass `json:"priorityClasses"`
+}
+
+// PriorityClass is the Kubernetes PriorityClass of the workers whose priority is at least MinPriority. If Users
+// isn't empty, only these users can use it. kservice doesn't authenticate the caller and Users is matched against
+// the userinfo.username in request, so it's advisory: it keeps well-behaved clients off the class but doesn't stop
+// a caller who claims another user. Put kservice behind an authenticating proxy which sets the username if the
+// policy must be enforced.
+type PriorityClass struct {
+	Name        string   `json:"name"`
+	MinPriority int32    `json:"minPriority"`
+	Users       []string `json:"users,omitempty"`
 }
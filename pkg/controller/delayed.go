@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// delayedSyncPeriod is the interval to check whether the delayed workers are due.
+const delayedSyncPeriod time.Duration = 2 * time.Second
+
+// DelayedController creates the delayed workers persisted in ConfigMaps when they're due. Every replica of kservice
+// may run it: a replica claims the delayed worker by updating its ConfigMap with optimistic concurrency before
+// creating the Pod, and the Pod has the deterministic name, so the worker is created exactly once.
+type DelayedController struct {
+	namespaces   []string
+	queueEnabled bool
+	kubeClient   kubernetes.Interface
+}
+
+// NewDelayedController creates the DelayedController with the default clients.
+func NewDelayedController(g *config.Config) *DelayedController {
+	return &DelayedController{
+		namespaces:   g.AllowedNamespaces,
+		queueEnabled: g.IsQueueEnabled(),
+		kubeClient:   apitypes.DefaultKubeClient(),
+	}
+}
+
+// Run checks the delayed workers periodically until ctx is done.
+func (c *DelayedController) Run(ctx context.Context) {
+	logger.InfoFields("Start delayed worker controller", logger.Fields{"namespaces": c.namespaces})
+	wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.syncNamespace(ctx, ns)
+		}
+	}, delayedSyncPeriod, ctx.Done())
+	logger.Info("Stop delayed worker controller")
+}
+
+func (c *DelayedController) syncNamespace(ctx context.Context, namespace string) {
+	cms, err := c.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelDelayedWorker,
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list delayed workers", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	now := time.Now()
+	for i := range cms.Items {
+		if !adapter.IsDelayedWorkerDue(&cms.Items[i], now) {
+			continue
+		}
+		if err = c.fire(ctx, &cms.Items[i]); err != nil {
+			logger.ErrorFields("Fail to create delayed worker", logger.Fields{
+				apitypes.LogWorkerName:      cms.Items[i].GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
+
+// fire claims the delayed worker, creates its Pod and deletes the ConfigMap. The delayed worker which can't be
+// created is kept as Failed. If admission queue is enabled, the ConfigMap is turned into the queued worker instead.
+func (c *DelayedController) fire(ctx context.Context, cm *corev1.ConfigMap) error {
+	cmClient := c.kubeClient.CoreV1().ConfigMaps(cm.GetNamespace())
+	adapter.SetDelayedPhase(cm, apitypes.DelayedPhaseFiring, "")
+	cm, err := cmClient.Update(ctx, cm, metav1.UpdateOptions{})
+	if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+		// It's claimed by another replica or cancelled.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, pod, err := adapter.TranslateConfigMapToDelayedWorker(cm)
+	if err == nil && c.queueEnabled {
+		// The delayed worker waits in admission queue in the same ConfigMap.
+		queuedCM := cm.DeepCopy()
+		if err = adapter.SetQueuedConfigMap(queuedCM, pod); err == nil {
+			_, err = cmClient.Update(ctx, queuedCM, metav1.UpdateOptions{})
+			if err == nil || k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+				return nil
+			}
+		}
+	} else if err == nil {
+		_, err = c.kubeClient.CoreV1().Pods(pod.GetNamespace()).Create(ctx, pod, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		adapter.SetDelayedPhase(cm, apitypes.DelayedPhaseFailed, err.Error())
+		if _, updateErr := cmClient.Update(ctx, cm, metav1.UpdateOptions{}); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+	logger.InfoFields("Successfully create delayed worker", logger.Fields{
+		apitypes.LogWorkerName:      pod.GetName(),
+		apitypes.LogWorkerNamespace: pod.GetNamespace(),
+	})
+	resourceVersion := cm.GetResourceVersion()
+	err = cmClient.Delete(ctx, cm.GetName(), metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// LabelLegacyWorkers adds LabelManagedWorker to the worker Pods created before the label is introduced, so that
+// they're still listed, counted by the admission queue and handled on preemption. Such a Pod has no owner and
+// isn't a debug Pod, and its container is named with the container name prefix of kservice, as the Pods of
+// services and Jobs are owned by their controllers.
+func LabelLegacyWorkers(ctx context.Context, g *config.Config) {
+	kubeClient := apitypes.DefaultKubeClient()
+	for _, ns := range g.AllowedNamespaces {
+		if err := labelLegacyWorkers(ctx, kubeClient, ns, g.ContainerNamePrefix); err != nil {
+			logger.ErrorFields("Fail to label legacy workers", logger.Fields{
+				apitypes.LogWorkerNamespace: ns,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
+
+func labelLegacyWorkers(ctx context.Context, kubeClient kubernetes.Interface, namespace, containerPrefix string) error {
+	pods, err := kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "!" + apitypes.LabelManagedWorker + ",!" + apitypes.LabelDebugFor,
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{apitypes.LabelManagedWorker: apitypes.ManagedWorkerValue},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !isLegacyWorker(pod, containerPrefix) {
+			continue
+		}
+		_, err := kubeClient.CoreV1().Pods(namespace).Patch(ctx, pod.GetName(), k8stypes.StrategicMergePatchType,
+			data, metav1.PatchOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		logger.InfoFields("Label legacy worker", logger.Fields{
+			apitypes.LogWorkerName:      pod.GetName(),
+			apitypes.LogWorkerNamespace: namespace,
+		})
+	}
+
+	return nil
+}
+
+// isLegacyWorker returns true if the Pod is created for a worker by kservice before LabelManagedWorker is
+// introduced. The Pod of Worker custom resource may be owned by its Worker.
+func isLegacyWorker(pod *corev1.Pod, containerPrefix string) bool {
+	if len(pod.GetOwnerReferences()) > 0 && pod.Labels[apitypes.LabelWorker] == "" {
+		return false
+	}
+
+	return len(pod.Spec.Containers) > 0 && strings.HasPrefix(pod.Spec.Containers[0].Name, containerPrefix)
+}
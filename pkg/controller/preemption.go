@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// preemptionSyncPeriod is the interval to check the preemption events.
+	preemptionSyncPeriod time.Duration = 5 * time.Second
+	// preemptedRecordTTL is the time to keep the status of preempted worker after its Pod is deleted.
+	preemptedRecordTTL time.Duration = 24 * time.Hour
+	// reasonPreempted is the reason of event recorded by scheduler for the preempted Pod.
+	reasonPreempted string = "Preempted"
+)
+
+// PreemptionController surfaces the workers preempted by scheduler for the workers with higher priority. Scheduler
+// deletes the preempted Pod, so its status is annotated with the preempting worker and kept in a ConfigMap after
+// the Pod is gone. Only the worker Pods created by kservice are recorded, so the preempted Pod which is already
+// gone when the event is handled is skipped since it can't be told apart. Every replica of kservice may run it
+// since the same status is recorded.
+type PreemptionController struct {
+	namespaces []string
+	kubeClient kubernetes.Interface
+	// handled is the time when the preemption events are recorded by their UIDs.
+	handled sync.Map
+}
+
+// NewPreemptionController creates the PreemptionController with the default clients.
+func NewPreemptionController(g *config.Config) *PreemptionController {
+	return &PreemptionController{
+		namespaces: g.AllowedNamespaces,
+		kubeClient: apitypes.DefaultKubeClient(),
+	}
+}
+
+// Run checks the preemption events periodically until ctx is done.
+func (c *PreemptionController) Run(ctx context.Context) {
+	logger.InfoFields("Start preemption controller", logger.Fields{"namespaces": c.namespaces})
+	wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.syncNamespace(ctx, ns)
+			c.cleanup(ctx, ns)
+		}
+	}, preemptionSyncPeriod, ctx.Done())
+	logger.Info("Stop preemption controller")
+}
+
+func (c *PreemptionController) syncNamespace(ctx context.Context, namespace string) {
+	events, err := c.kubeClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("reason", reasonPreempted),
+			fields.OneTermEqualSelector("involvedObject.kind", "Pod"),
+		).String(),
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list preemption events", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	for i := range events.Items {
+		event := &events.Items[i]
+		if _, ok := c.handled.Load(event.GetUID()); ok {
+			continue
+		}
+		if err = c.record(ctx, event); err != nil {
+			logger.ErrorFields("Fail to record preempted worker", logger.Fields{
+				apitypes.LogWorkerName:      event.InvolvedObject.Name,
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+			continue
+		}
+		c.handled.Store(event.GetUID(), time.Now())
+	}
+}
+
+// record annotates the preempted worker Pod and keeps its status. The Pod which isn't created by kservice, or is
+// recreated with the same name after the preemption, isn't touched.
+func (c *PreemptionController) record(ctx context.Context, event *corev1.Event) error {
+	namespace, name := event.InvolvedObject.Namespace, event.InvolvedObject.Name
+	preemptor := adapter.PreemptorOf(event)
+	podClient := c.kubeClient.CoreV1().Pods(namespace)
+	pod, err := podClient.Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) || (err == nil && pod.GetUID() != event.InvolvedObject.UID) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if pod.GetLabels()[apitypes.LabelManagedWorker] != apitypes.ManagedWorkerValue {
+		return nil
+	}
+	if pod.Annotations[apitypes.AnnotationPreemptedBy] == "" {
+		err = patchPod(ctx, c.kubeClient, pod, map[string]string{apitypes.AnnotationPreemptedBy: preemptor}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	cm, err := adapter.TranslatePreemptedConfigMap(namespace, name, pod, preemptor)
+	if err != nil {
+		return err
+	}
+	_, err = c.kubeClient.CoreV1().ConfigMaps(namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	logger.InfoFields("Worker is preempted", logger.Fields{
+		apitypes.LogWorkerName:      name,
+		apitypes.LogWorkerNamespace: namespace,
+		"preemptedBy":               preemptor,
+	})
+
+	return nil
+}
+
+// cleanup deletes the expired status of preempted workers and forgets the events which have expired too.
+func (c *PreemptionController) cleanup(ctx context.Context, namespace string) {
+	now := time.Now()
+	c.handled.Range(func(uid, handledAt interface{}) bool {
+		if now.Sub(handledAt.(time.Time)) > preemptedRecordTTL {
+			c.handled.Delete(uid)
+		}
+		return true
+	})
+	cms, err := c.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelPreemptedWorker,
+	})
+	if err != nil {
+		return
+	}
+	for i := range cms.Items {
+		if now.Sub(cms.Items[i].GetCreationTimestamp().Time) < preemptedRecordTTL {
+			continue
+		}
+		uid := cms.Items[i].GetUID()
+		err = c.kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, cms.Items[i].GetName(), metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &uid},
+		})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			logger.ErrorFields("Fail to delete status of preempted worker", logger.Fields{
+				apitypes.LogWorkerName:      cms.Items[i].GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// queueSyncPeriod is the interval to dispatch the queued workers.
+const queueSyncPeriod time.Duration = 2 * time.Second
+
+// QueueController creates the workers in admission queue by priority and submission order as long as the limits of
+// active workers allow. Every replica of kservice may run it. The queue is listed before the active Pods and the
+// queued worker is removed only after its Pod is created, so a worker missing from the queue is always counted as
+// active. A replica claims the queued worker before creating the Pod, which has the deterministic name.
+//
+// The limits are soft. Every replica counts the usage on its own and claims a different queued worker, so N replicas
+// dispatching at the same time may overshoot a limit by up to N workers. The queued Job is counted as one worker
+// until its Pods are created, so it may overshoot by its parallelism too. The Jobs created by CronJob and the Pods
+// of service Deployment never go through the queue and only the Pods of the former are counted.
+type QueueController struct {
+	namespaces      []string
+	maxWorkers      int
+	maxPerNamespace int
+	maxPerUser      int
+	kubeClient      kubernetes.Interface
+}
+
+// NewQueueController creates the QueueController with the default clients.
+func NewQueueController(g *config.Config) *QueueController {
+	return &QueueController{
+		namespaces:      g.AllowedNamespaces,
+		maxWorkers:      g.MaxWorkers,
+		maxPerNamespace: g.MaxWorkersPerNamespace,
+		maxPerUser:      g.MaxWorkersPerUser,
+		kubeClient:      apitypes.DefaultKubeClient(),
+	}
+}
+
+// queueUsage is the number of active workers in all allowed namespaces, in every namespace and of every user.
+type queueUsage struct {
+	total      int
+	namespaces map[string]int
+	users      map[string]int
+	activePods map[string]bool
+}
+
+func (u *queueUsage) add(pod *corev1.Pod) {
+	key := pod.GetNamespace() + "/" + pod.GetName()
+	if u.activePods[key] {
+		return
+	}
+	u.activePods[key] = true
+	u.total++
+	u.namespaces[pod.GetNamespace()]++
+	if user := adapter.PodUser(pod); user != "" {
+		u.users[user]++
+	}
+}
+
+// Run dispatches the queued workers periodically until ctx is done.
+func (c *QueueController) Run(ctx context.Context) {
+	logger.InfoFields("Start queue controller", logger.Fields{
+		"namespaces":      c.namespaces,
+		"maxWorkers":      c.maxWorkers,
+		"maxPerNamespace": c.maxPerNamespace,
+		"maxPerUser":      c.maxPerUser,
+	})
+	wait.Until(func() {
+		if err := c.dispatch(ctx); err != nil {
+			logger.ErrorFields("Fail to dispatch queued workers", logger.Fields{logger.ERROR: err})
+		}
+	}, queueSyncPeriod, ctx.Done())
+	logger.Info("Stop queue controller")
+}
+
+func (c *QueueController) dispatch(ctx context.Context) error {
+	queue := []corev1.ConfigMap{}
+	for _, ns := range c.namespaces {
+		cms, err := c.kubeClient.CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: apitypes.LabelQueuedWorker,
+		})
+		if err != nil {
+			return err
+		}
+		queue = append(queue, cms.Items...)
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+	adapter.SortQueue(queue)
+	usage, err := c.activeUsage(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range queue {
+		cm := &queue[i]
+		if adapter.IsQueuedWorkerFailed(cm) {
+			continue
+		}
+		_, pod, err := adapter.TranslateConfigMapToQueuedWorker(cm)
+		if err != nil {
+			c.setPhase(ctx, cm, apitypes.QueuePhaseFailed, err.Error())
+			continue
+		}
+		// The worker being created by another replica is active already.
+		if adapter.IsQueuedWorkerClaimed(cm, now) {
+			usage.add(pod)
+			continue
+		}
+		if reason := c.limitReason(usage, pod); reason != "" {
+			c.setPhase(ctx, cm, apitypes.QueuePhaseQueued, reason)
+			continue
+		}
+		if err = c.create(ctx, cm, pod); err != nil {
+			logger.ErrorFields("Fail to create queued worker", logger.Fields{
+				apitypes.LogWorkerName:      cm.GetName(),
+				apitypes.LogWorkerNamespace: cm.GetNamespace(),
+				logger.ERROR:                err,
+			})
+			continue
+		}
+		usage.add(pod)
+	}
+
+	return nil
+}
+
+// activeUsage counts the worker Pods and the Pods of Job created by kservice which are neither succeeded nor failed
+// in all allowed namespaces.
+func (c *QueueController) activeUsage(ctx context.Context) (*queueUsage, error) {
+	usage := &queueUsage{
+		namespaces: map[string]int{},
+		users:      map[string]int{},
+		activePods: map[string]bool{},
+	}
+	selector := fields.AndSelectors(
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+		fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+	).String()
+	labelSelectors := []string{
+		adapter.ManagedWorkerSelector(""),
+		apitypes.LabelJobPod + "=" + apitypes.ManagedWorkerValue,
+	}
+	for _, ns := range c.namespaces {
+		for _, labelSelector := range labelSelectors {
+			pods, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+				LabelSelector: labelSelector,
+				FieldSelector: selector,
+			})
+			if err != nil {
+				return nil, err
+			}
+			for i := range pods.Items {
+				if pods.Items[i].GetDeletionTimestamp() == nil {
+					usage.add(&pods.Items[i])
+				}
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// limitReason returns why the Pod can't be created yet or empty string if all limits allow it.
+func (c *QueueController) limitReason(usage *queueUsage, pod *corev1.Pod) string {
+	if c.maxWorkers > 0 && usage.total >= c.maxWorkers {
+		return fmt.Sprintf("Waiting for the limit of %d active workers", c.maxWorkers)
+	}
+	if c.maxPerNamespace > 0 && usage.namespaces[pod.GetNamespace()] >= c.maxPerNamespace {
+		return fmt.Sprintf("Waiting for the limit of %d active workers in namespace %s", c.maxPerNamespace,
+			pod.GetNamespace())
+	}
+	if user := adapter.PodUser(pod); c.maxPerUser > 0 && user != "" && usage.users[user] >= c.maxPerUser {
+		return fmt.Sprintf("Waiting for the limit of %d active workers of user %s", c.maxPerUser, user)
+	}
+
+	return ""
+}
+
+// create claims the queued worker, creates its Pod or Job and deletes the ConfigMap. The queued worker which can't
+// be created is kept as Failed.
+func (c *QueueController) create(ctx context.Context, cm *corev1.ConfigMap, pod *corev1.Pod) error {
+	cmClient := c.kubeClient.CoreV1().ConfigMaps(cm.GetNamespace())
+	cm = cm.DeepCopy()
+	adapter.SetQueuedPhase(cm, apitypes.QueuePhaseDispatching, "")
+	cm, err := cmClient.Update(ctx, cm, metav1.UpdateOptions{})
+	if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+		// It's claimed by another replica, reordered or dropped.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	job, err := adapter.QueuedJob(cm)
+	if err == nil && job != nil {
+		_, err = c.kubeClient.BatchV1().Jobs(job.GetNamespace()).Create(ctx, job, metav1.CreateOptions{})
+	} else if err == nil {
+		_, err = c.kubeClient.CoreV1().Pods(pod.GetNamespace()).Create(ctx, pod, metav1.CreateOptions{})
+	}
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		adapter.SetQueuedPhase(cm, apitypes.QueuePhaseFailed, err.Error())
+		if _, updateErr := cmClient.Update(ctx, cm, metav1.UpdateOptions{}); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+	logger.InfoFields("Successfully create queued worker", logger.Fields{
+		apitypes.LogWorkerName:      pod.GetName(),
+		apitypes.LogWorkerNamespace: pod.GetNamespace(),
+	})
+	resourceVersion := cm.GetResourceVersion()
+	err = cmClient.Delete(ctx, cm.GetName(), metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+	})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// setPhase records the phase and message of queued worker if they're changed.
+func (c *QueueController) setPhase(ctx context.Context, cm *corev1.ConfigMap, phase, message string) {
+	cm = cm.DeepCopy()
+	if !adapter.SetQueuedPhase(cm, phase, message) {
+		return
+	}
+	_, err := c.kubeClient.CoreV1().ConfigMaps(cm.GetNamespace()).Update(ctx, cm, metav1.UpdateOptions{})
+	if err != nil && !k8serrors.IsConflict(err) && !k8serrors.IsNotFound(err) {
+		logger.ErrorFields("Fail to update queued worker", logger.Fields{
+			apitypes.LogWorkerName:      cm.GetName(),
+			apitypes.LogWorkerNamespace: cm.GetNamespace(),
+			logger.ERROR:                err,
+		})
+	}
+}
+
+// createPod creates the worker Pod, or holds it in admission queue if queue is true so that it's created when the
+// limits allow. The Pod has the deterministic name, which is the name of its queued worker too, so the Pod created
+// or queued by another replica is taken as success.
+func createPod(ctx context.Context, kubeClient kubernetes.Interface, pod *corev1.Pod, queue bool) error {
+	if !queue {
+		_, err := kubeClient.CoreV1().Pods(pod.GetNamespace()).Create(ctx, pod, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	cm, err := adapter.TranslatePodToQueuedConfigMap(pod)
+	if err != nil {
+		return err
+	}
+	_, err = kubeClient.CoreV1().ConfigMaps(cm.GetNamespace()).Create(ctx, cm, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+// getQueuedPod gets the Pod, or its queued worker ConfigMap if it's still in admission queue. The queued worker is
+// deleted right after its Pod is created, so the Pod is got again if neither is found at first. It returns the
+// NotFound error if the Pod is neither created nor queued.
+func getQueuedPod(ctx context.Context, kubeClient kubernetes.Interface, namespace,
+	name string) (*corev1.Pod, *corev1.ConfigMap, error) {
+	pod, err := kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return pod, nil, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil && cm.GetLabels()[apitypes.LabelQueuedWorker] == name {
+		return nil, cm, nil
+	}
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	pod, err = kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pod, nil, nil
+}
+
+// dropQueuedPod removes the Pod from admission queue if it's still there. The queued worker claimed by a replica
+// is removed too, in which case its Pod may still be created.
+func dropQueuedPod(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string) error {
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) || (err == nil && cm.GetLabels()[apitypes.LabelQueuedWorker] != name) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	uid := cm.GetUID()
+	err = kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &uid},
+	})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// retrySyncPeriod is the interval to check the attempts of workers with retry policy.
+	retrySyncPeriod time.Duration = 5 * time.Second
+	// reasonImagePullTimeout is the reason of attempt failed because it can't pull image in time.
+	reasonImagePullTimeout string = "ImagePullTimeout"
+)
+
+// RetryController creates the next attempt of failed worker according to its retry policy. Every attempt is a Pod
+// which is kept as history. The next attempt has the deterministic name and the failed attempt is annotated after
+// it's created, so the worker is retried once per failure even if several replicas of kservice run it.
+type RetryController struct {
+	namespaces   []string
+	queueEnabled bool
+	kubeClient   kubernetes.Interface
+}
+
+// NewRetryController creates the RetryController with the default clients.
+func NewRetryController(g *config.Config) *RetryController {
+	return &RetryController{
+		namespaces:   g.AllowedNamespaces,
+		queueEnabled: g.IsQueueEnabled(),
+		kubeClient:   apitypes.DefaultKubeClient(),
+	}
+}
+
+// Run checks the workers with retry policy periodically until ctx is done.
+func (c *RetryController) Run(ctx context.Context) {
+	logger.InfoFields("Start retry controller", logger.Fields{"namespaces": c.namespaces})
+	wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.syncNamespace(ctx, ns)
+		}
+	}, retrySyncPeriod, ctx.Done())
+	logger.Info("Stop retry controller")
+}
+
+func (c *RetryController) syncNamespace(ctx context.Context, namespace string) {
+	pods, err := c.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelRetryOf,
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list attempts", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	// Only the latest attempt of every worker is retried.
+	latest := map[string]*corev1.Pod{}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		id := pod.Labels[apitypes.LabelRetryOf]
+		if last, ok := latest[id]; !ok || adapter.PodAttempt(pod) > adapter.PodAttempt(last) {
+			latest[id] = pod
+		}
+	}
+	now := time.Now()
+	for id, pod := range latest {
+		if err = c.sync(ctx, pod, now); err != nil {
+			logger.ErrorFields("Fail to retry worker", logger.Fields{
+				apitypes.LogWorkerName:      id,
+				apitypes.LogWorkerNamespace: namespace,
+				"attempt":                   pod.GetName(),
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
+
+// sync fails the latest attempt which can't pull image in time and retries the failed one after backoff.
+func (c *RetryController) sync(ctx context.Context, pod *corev1.Pod, now time.Time) error {
+	if pod.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if _, retried := pod.Annotations[apitypes.AnnotationRetried]; retried {
+		return nil
+	}
+	policy, err := adapter.PodRetryPolicy(pod)
+	if err != nil {
+		return err
+	}
+	if adapter.IsImagePullStuck(pod, policy, now) {
+		return failPod(ctx, c.kubeClient, pod, reasonImagePullTimeout, "Worker can't pull image in time")
+	}
+	attempt := adapter.PodAttempt(pod)
+	if attempt >= policy.MaxAttempts || !adapter.IsAttemptRetryable(pod, policy) {
+		return nil
+	}
+	if now.Before(adapter.AttemptFinishTime(pod).Add(adapter.RetryDelay(policy, attempt))) {
+		return nil
+	}
+
+	next := adapter.TranslateNextAttempt(pod)
+	// The next attempt waits in admission queue like any other worker if it's enabled.
+	if err = createPod(ctx, c.kubeClient, next, c.queueEnabled); err != nil {
+		return err
+	}
+	logger.InfoFields("Successfully retry worker", logger.Fields{
+		apitypes.LogWorkerName:      pod.Labels[apitypes.LabelRetryOf],
+		apitypes.LogWorkerNamespace: pod.GetNamespace(),
+		"attempt":                   next.GetName(),
+	})
+
+	return patchPod(ctx, c.kubeClient, pod, map[string]string{apitypes.AnnotationRetried: next.GetName()}, nil)
+}
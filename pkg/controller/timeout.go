@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// timeoutSyncPeriod is the interval to check the workers staying in Pending.
+const timeoutSyncPeriod time.Duration = 5 * time.Second
+
+// TimeoutController fails the workers which stay in Pending longer than their maxPending with reason TimedOut.
+// The failed Pod is kept so that its status can still be queried. The maxRuntime is enforced by kubelet with
+// activeDeadlineSeconds.
+type TimeoutController struct {
+	namespaces []string
+	kubeClient kubernetes.Interface
+}
+
+// NewTimeoutController creates the TimeoutController with the default clients.
+func NewTimeoutController(g *config.Config) *TimeoutController {
+	return &TimeoutController{
+		namespaces: g.AllowedNamespaces,
+		kubeClient: apitypes.DefaultKubeClient(),
+	}
+}
+
+// Run checks the pending workers periodically until ctx is done.
+func (c *TimeoutController) Run(ctx context.Context) {
+	logger.InfoFields("Start timeout controller", logger.Fields{"namespaces": c.namespaces})
+	wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.syncNamespace(ctx, ns)
+		}
+	}, timeoutSyncPeriod, ctx.Done())
+	logger.Info("Stop timeout controller")
+}
+
+func (c *TimeoutController) syncNamespace(ctx context.Context, namespace string) {
+	pods, err := c.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: apitypes.LabelMaxPending,
+		FieldSelector: fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)).String(),
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list pending workers", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	now := time.Now()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.GetDeletionTimestamp() != nil || pod.Annotations[apitypes.AnnotationFailReason] != "" {
+			continue
+		}
+		maxPending, err := strconv.ParseInt(pod.Labels[apitypes.LabelMaxPending], 10, 64)
+		if err != nil || maxPending <= 0 {
+			continue
+		}
+		if now.Sub(pod.GetCreationTimestamp().Time) < time.Duration(maxPending)*time.Second {
+			continue
+		}
+		err = failPod(ctx, c.kubeClient, pod, apitypes.WorkerStatusReasonTimedOut, "Worker exceeds maxPending")
+		if err != nil {
+			logger.ErrorFields("Fail to time out pending worker", logger.Fields{
+				apitypes.LogWorkerName:      pod.GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
+
+// failPod fails the Pod on purpose with the reason which is reported instead of the Pod's. The scheduled Pod is
+// failed by kubelet with its active deadline, whose original value is kept for the next attempt of worker. The
+// Pod not scheduled yet has no kubelet, so its status is updated directly and scheduler will ignore it.
+func failPod(ctx context.Context, kubeClient kubernetes.Interface, pod *corev1.Pod, reason, message string) error {
+	logger.InfoFields("Fail worker", logger.Fields{
+		apitypes.LogWorkerName:      pod.GetName(),
+		apitypes.LogWorkerNamespace: pod.GetNamespace(),
+		"reason":                    reason,
+	})
+	deadline := int64(0)
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		deadline = *pod.Spec.ActiveDeadlineSeconds
+	}
+	annotations := map[string]string{
+		apitypes.AnnotationFailReason:     reason,
+		apitypes.AnnotationActiveDeadline: strconv.FormatInt(deadline, 10),
+	}
+	if pod.Spec.NodeName != "" {
+		return patchPod(ctx, kubeClient, pod, annotations, map[string]interface{}{"activeDeadlineSeconds": 1})
+	}
+
+	// Mark the Pod failed with its resource version, so that it's skipped if it's scheduled meanwhile.
+	pod = pod.DeepCopy()
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = reason
+	pod.Status.Message = message
+	pod, err := kubeClient.CoreV1().Pods(pod.GetNamespace()).UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return patchPod(ctx, kubeClient, pod, annotations, nil)
+}
+
+// patchPod patches the annotations and spec of Pod. The Pod which doesn't exist any more is ignored.
+func patchPod(ctx context.Context, kubeClient kubernetes.Interface, pod *corev1.Pod, annotations map[string]string,
+	spec map[string]interface{}) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	}
+	if spec != nil {
+		patch["spec"] = spec
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	_, err = kubeClient.CoreV1().Pods(pod.GetNamespace()).Patch(ctx, pod.GetName(), k8stypes.StrategicMergePatchType,
+		data, metav1.PatchOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,359 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// resyncPeriod is the interval to reconcile all Workers even if nothing changes.
+	resyncPeriod time.Duration = 5 * time.Minute
+	// reasonInvalidSpec is the reason of Worker whose spec can't be translated into Pod.
+	reasonInvalidSpec string = "InvalidSpec"
+	// reasonNameConflict is the reason of Worker whose name is taken by a Pod not created for it.
+	reasonNameConflict string = "NameConflict"
+)
+
+// WorkerController reconciles the Worker custom resources in CRD namespace into Pods and writes the Pod status back
+// to the status of Worker.
+type WorkerController struct {
+	namespace      string
+	queueEnabled   bool
+	kubeClient     kubernetes.Interface
+	workerClient   dynamic.ResourceInterface
+	workerInformer cache.SharedIndexInformer
+	// podInformers are the informers of Worker Pods in every allowed namespace.
+	podInformers map[string]cache.SharedIndexInformer
+	queue        workqueue.RateLimitingInterface
+}
+
+// NewWorkerController creates the WorkerController with the default clients.
+func NewWorkerController(g *config.Config) *WorkerController {
+	c := &WorkerController{
+		namespace:    g.CRDNamespace,
+		queueEnabled: g.IsQueueEnabled(),
+		kubeClient:   apitypes.DefaultKubeClient(),
+		workerClient: apitypes.DefaultDynamicClient().Resource(apitypes.WorkerResource).Namespace(g.CRDNamespace),
+		podInformers: make(map[string]cache.SharedIndexInformer),
+		queue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "workers"),
+	}
+	c.workerInformer = cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			return c.workerClient.List(config.ContextRoot, opts)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			return c.workerClient.Watch(config.ContextRoot, opts)
+		},
+	}, &unstructured.Unstructured{}, resyncPeriod, cache.Indexers{})
+	c.workerInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueueWorker,
+		UpdateFunc: func(_, obj interface{}) { c.enqueueWorker(obj) },
+		DeleteFunc: c.enqueueWorker,
+	})
+	for _, ns := range g.AllowedNamespaces {
+		podInformer := cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(c.kubeClient.CoreV1().RESTClient(), "pods", ns,
+				func(opts *metav1.ListOptions) {
+					opts.LabelSelector = apitypes.LabelWorker
+				}),
+			&corev1.Pod{}, resyncPeriod, cache.Indexers{})
+		podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueuePod,
+			UpdateFunc: func(_, obj interface{}) { c.enqueuePod(obj) },
+			DeleteFunc: c.enqueuePod,
+		})
+		c.podInformers[ns] = podInformer
+	}
+
+	return c
+}
+
+func (c *WorkerController) enqueueWorker(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		logger.ErrorFields("Fail to get key of Worker", logger.Fields{logger.ERROR: err})
+		return
+	}
+	_, name, _ := cache.SplitMetaNamespaceKey(key)
+	c.queue.Add(name)
+}
+
+func (c *WorkerController) enqueuePod(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	if name := pod.GetLabels()[apitypes.LabelWorker]; name != "" {
+		c.queue.Add(name)
+	}
+}
+
+// Run starts the informers and the given number of workers to reconcile Workers until ctx is done.
+func (c *WorkerController) Run(ctx context.Context, threads int) {
+	defer c.queue.ShutDown()
+	logger.InfoFields("Start Worker controller", logger.Fields{
+		"namespace": c.namespace,
+		"threads":   threads,
+	})
+	hasSynced := []cache.InformerSynced{c.workerInformer.HasSynced}
+	go c.workerInformer.Run(ctx.Done())
+	for _, podInformer := range c.podInformers {
+		go podInformer.Run(ctx.Done())
+		hasSynced = append(hasSynced, podInformer.HasSynced)
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), hasSynced...) {
+		logger.Error("Fail to sync the caches of Worker controller")
+		return
+	}
+	for i := 0; i < threads; i++ {
+		go wait.Until(func() {
+			for c.processNextItem(ctx) {
+			}
+		}, time.Second, ctx.Done())
+	}
+	<-ctx.Done()
+	logger.Info("Stop Worker controller")
+}
+
+func (c *WorkerController) processNextItem(ctx context.Context) bool {
+	item, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(item)
+	name := item.(string)
+	if err := c.sync(ctx, name); err != nil {
+		logger.ErrorFields("Fail to reconcile Worker", logger.Fields{
+			apitypes.LogWorkerName: name,
+			logger.ERROR:           err,
+		})
+		c.queue.AddRateLimited(item)
+		return true
+	}
+	c.queue.Forget(item)
+
+	return true
+}
+
+// sync reconciles the Worker. The Pod is created once and never recreated, so the Worker whose Pod is deleted
+// before it terminates is marked as cancelled.
+func (c *WorkerController) sync(ctx context.Context, name string) error {
+	obj, exists, err := c.workerInformer.GetIndexer().GetByKey(c.namespace + "/" + name)
+	if err != nil || !exists {
+		return err
+	}
+	u := obj.(*unstructured.Unstructured).DeepCopy()
+	worker, err := adapter.TranslateUnstructuredToWorker(u)
+	if err != nil {
+		return err
+	}
+	if worker.GetDeletionTimestamp() != nil {
+		return c.finalize(ctx, u, worker)
+	}
+	if !hasFinalizer(u) {
+		u.SetFinalizers(append(u.GetFinalizers(), apitypes.FinalizerWorker))
+		_, err = c.workerClient.Update(ctx, u, metav1.UpdateOptions{})
+		return err
+	}
+
+	status := &v1types.WorkerResourceStatus{}
+	if worker.Status != nil {
+		*status = *worker.Status
+	}
+	if status.PodName == "" {
+		ctx = context.WithValue(ctx, apitypes.LogCtxID, uuid.New())
+		podObj, err := adapter.TranslateWorkerToPod(ctx, worker)
+		if err != nil {
+			status.Status = string(corev1.PodFailed)
+			status.State = apitypes.WorkerStatusTerminated
+			status.Reason, status.Message = reasonInvalidSpec, err.Error()
+			status.ObservedGeneration = worker.GetGeneration()
+			return c.updateStatus(ctx, u, worker.Status, status)
+		}
+		if c.queueEnabled {
+			// The Pod is created by the queue controller when the limits allow.
+			if err = createPod(ctx, c.kubeClient, podObj, true); err != nil {
+				return err
+			}
+			logger.InfoFields("Successfully queue Pod for Worker", logger.Fields{
+				apitypes.LogWorkerName:      podObj.GetName(),
+				apitypes.LogWorkerNamespace: podObj.GetNamespace(),
+			})
+			status.PodName, status.PodNamespace = podObj.GetName(), podObj.GetNamespace()
+			status.ObservedGeneration = worker.GetGeneration()
+			status.WorkerStatus = v1types.WorkerStatus{
+				Status: apitypes.QueuePhaseQueued,
+				State:  apitypes.WorkerStatusQueued,
+				Reason: apitypes.WorkerStatusQueued,
+			}
+			return c.updateStatus(ctx, u, worker.Status, status)
+		}
+		pod, err := c.kubeClient.CoreV1().Pods(podObj.GetNamespace()).Create(ctx, podObj, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			pod, err = c.kubeClient.CoreV1().Pods(podObj.GetNamespace()).Get(ctx, podObj.GetName(), metav1.GetOptions{})
+			// Only the Pod created for this Worker is adopted, otherwise it would be deleted with the Worker.
+			if err == nil && !adapter.IsPodOfWorker(worker, pod) {
+				setNameConflict(status, pod)
+				status.ObservedGeneration = worker.GetGeneration()
+				return c.updateStatus(ctx, u, worker.Status, status)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		logger.InfoFields("Successfully create Pod for Worker", logger.Fields{
+			apitypes.LogWorkerName:      pod.GetName(),
+			apitypes.LogWorkerNamespace: pod.GetNamespace(),
+		})
+		status.PodName, status.PodNamespace = pod.GetName(), pod.GetNamespace()
+		status.ObservedGeneration = worker.GetGeneration()
+		status.WorkerStatus = *adapter.TranslatePodStatus(pod)
+
+		return c.updateStatus(ctx, u, worker.Status, status)
+	}
+
+	pod, err := c.getPod(ctx, status.PodNamespace, status.PodName)
+	if k8serrors.IsNotFound(err) && c.queueEnabled {
+		var cm *corev1.ConfigMap
+		pod, cm, err = getQueuedPod(ctx, c.kubeClient, status.PodNamespace, status.PodName)
+		if cm != nil {
+			if workerStatus := adapter.TranslateQueuedStatus(cm); workerStatus != nil {
+				status.WorkerStatus = *workerStatus
+			}
+			return c.updateStatus(ctx, u, worker.Status, status)
+		}
+	}
+	if k8serrors.IsNotFound(err) {
+		if status.State != apitypes.WorkerStatusTerminated {
+			status.Status = string(corev1.PodFailed)
+			status.State = apitypes.WorkerStatusTerminated
+			status.Reason, status.Message = apitypes.WorkerStatusReasonCancelled, "Pod is deleted"
+		}
+		return c.updateStatus(ctx, u, worker.Status, status)
+	}
+	if err != nil {
+		return err
+	}
+	// The queued Pod is created with AlreadyExists taken as success, so the Pod is adopted only if it's the Worker's.
+	if !adapter.IsPodOfWorker(worker, pod) {
+		if status.State != apitypes.WorkerStatusTerminated {
+			setNameConflict(status, pod)
+		}
+		return c.updateStatus(ctx, u, worker.Status, status)
+	}
+	status.WorkerStatus = *adapter.TranslatePodStatus(pod)
+
+	return c.updateStatus(ctx, u, worker.Status, status)
+}
+
+// setNameConflict fails the Worker whose Pod name is taken by another Pod.
+func setNameConflict(status *v1types.WorkerResourceStatus, pod *corev1.Pod) {
+	status.Status = string(corev1.PodFailed)
+	status.State = apitypes.WorkerStatusTerminated
+	status.Reason = reasonNameConflict
+	status.Message = fmt.Sprintf("Pod %s/%s exists and isn't created for the Worker", pod.GetNamespace(), pod.GetName())
+}
+
+// getPod gets the Pod from the informer cache. It falls back to Kubernetes API since the cache may not have the Pod
+// which is just created.
+func (c *WorkerController) getPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	if podInformer, ok := c.podInformers[namespace]; ok {
+		obj, exists, err := podInformer.GetIndexer().GetByKey(namespace + "/" + name)
+		if err == nil && exists {
+			return obj.(*corev1.Pod), nil
+		}
+	}
+
+	return c.kubeClient.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// updateStatus writes the status back to Worker if it changes.
+func (c *WorkerController) updateStatus(ctx context.Context, u *unstructured.Unstructured, oldStatus,
+	newStatus *v1types.WorkerResourceStatus) error {
+	newData, err := json.Marshal(newStatus)
+	if err != nil {
+		return err
+	}
+	if oldStatus != nil {
+		oldData, err := json.Marshal(oldStatus)
+		if err == nil && bytes.Equal(oldData, newData) {
+			return nil
+		}
+	}
+	statusObj := make(map[string]interface{})
+	if err = json.Unmarshal(newData, &statusObj); err != nil {
+		return err
+	}
+	u.Object["status"] = statusObj
+	_, err = c.workerClient.UpdateStatus(ctx, u, metav1.UpdateOptions{})
+
+	return err
+}
+
+// finalize deletes the Pod of Worker which is being deleted and then removes the finalizer.
+func (c *WorkerController) finalize(ctx context.Context, u *unstructured.Unstructured, worker *v1types.Worker) error {
+	if !hasFinalizer(u) {
+		return nil
+	}
+	if worker.Status != nil && worker.Status.PodName != "" {
+		if c.queueEnabled {
+			err := dropQueuedPod(ctx, c.kubeClient, worker.Status.PodNamespace, worker.Status.PodName)
+			if err != nil {
+				return fmt.Errorf("fail to drop queued Pod %s because of %v", worker.Status.PodName, err)
+			}
+		}
+		pod, err := c.getPod(ctx, worker.Status.PodNamespace, worker.Status.PodName)
+		if err == nil && adapter.IsPodOfWorker(worker, pod) {
+			err = c.kubeClient.CoreV1().Pods(worker.Status.PodNamespace).Delete(ctx, worker.Status.PodName,
+				metav1.DeleteOptions{})
+		}
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("fail to delete Pod %s because of %v", worker.Status.PodName, err)
+		}
+	}
+	finalizers := []string{}
+	for _, finalizer := range u.GetFinalizers() {
+		if finalizer != apitypes.FinalizerWorker {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	u.SetFinalizers(finalizers)
+	_, err := c.workerClient.Update(ctx, u, metav1.UpdateOptions{})
+
+	return err
+}
+
+func hasFinalizer(u *unstructured.Unstructured) bool {
+	for _, finalizer := range u.GetFinalizers() {
+		if finalizer == apitypes.FinalizerWorker {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,252 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jinghzhu/kservice/pkg/api/v1/adapter"
+	"github.com/jinghzhu/kservice/pkg/config"
+	"github.com/jinghzhu/kservice/pkg/logger"
+
+	apitypes "github.com/jinghzhu/kservice/pkg/api/types"
+	v1types "github.com/jinghzhu/kservice/pkg/api/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// workflowSyncPeriod is the interval to drive the running workflows.
+	workflowSyncPeriod time.Duration = 5 * time.Second
+	// workflowCleanupPeriod is the interval to delete the expired workflows.
+	workflowCleanupPeriod time.Duration = 10 * time.Minute
+	// workflowTTL is the time to keep the workflow after it finishes.
+	workflowTTL time.Duration = 7 * 24 * time.Hour
+)
+
+var (
+	// runningWorkflowSelector selects the workflows which aren't finished. The ones without phase label, which are
+	// created by the old version, are selected too.
+	runningWorkflowSelector = fmt.Sprintf("%s,%s notin (%s,%s)", apitypes.LabelWorkflow,
+		apitypes.LabelWorkflowPhase, apitypes.WorkflowPhaseSucceeded, apitypes.WorkflowPhaseFailed)
+	finishedWorkflowSelector = fmt.Sprintf("%s,%s in (%s,%s)", apitypes.LabelWorkflow,
+		apitypes.LabelWorkflowPhase, apitypes.WorkflowPhaseSucceeded, apitypes.WorkflowPhaseFailed)
+)
+
+// WorkflowController drives the workflows persisted in ConfigMaps of the allowed namespaces. Every replica of
+// kservice may run it: the step Pods have deterministic names and the status is saved with optimistic concurrency,
+// so a step is never run twice for the same attempt. The finished workflows are deleted after workflowTTL.
+type WorkflowController struct {
+	namespaces   []string
+	queueEnabled bool
+	kubeClient   kubernetes.Interface
+}
+
+// NewWorkflowController creates the WorkflowController with the default clients.
+func NewWorkflowController(g *config.Config) *WorkflowController {
+	return &WorkflowController{
+		namespaces:   g.AllowedNamespaces,
+		queueEnabled: g.IsQueueEnabled(),
+		kubeClient:   apitypes.DefaultKubeClient(),
+	}
+}
+
+// Run drives the workflows and deletes the expired ones periodically until ctx is done.
+func (c *WorkflowController) Run(ctx context.Context) {
+	logger.InfoFields("Start workflow controller", logger.Fields{"namespaces": c.namespaces})
+	go wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.cleanup(ctx, ns)
+		}
+	}, workflowCleanupPeriod, ctx.Done())
+	wait.Until(func() {
+		for _, ns := range c.namespaces {
+			c.syncNamespace(ctx, ns)
+		}
+	}, workflowSyncPeriod, ctx.Done())
+	logger.Info("Stop workflow controller")
+}
+
+func (c *WorkflowController) syncNamespace(ctx context.Context, namespace string) {
+	cms, err := c.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: runningWorkflowSelector,
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list workflows", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	for i := range cms.Items {
+		if err = c.sync(ctx, &cms.Items[i]); err != nil {
+			logger.ErrorFields("Fail to sync workflow", logger.Fields{
+				apitypes.LogWorkerName:      cms.Items[i].GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}
+
+// sync updates the running steps by their Pods, starts or skips the pending steps whose dependencies are finished
+// and saves the status if it changes.
+func (c *WorkflowController) sync(ctx context.Context, cm *corev1.ConfigMap) error {
+	wf, status, err := adapter.TranslateConfigMapToWorkflow(cm)
+	if err != nil {
+		return err
+	}
+	if status.Phase != apitypes.WorkflowPhaseRunning {
+		if cm.GetLabels()[apitypes.LabelWorkflowPhase] == status.Phase {
+			return nil
+		}
+		// The finished workflow created by the old version is labeled with its phase, so it's no longer listed.
+		if err = adapter.SetConfigMapWorkflowStatus(cm, status); err != nil {
+			return err
+		}
+		_, err = c.kubeClient.CoreV1().ConfigMaps(cm.GetNamespace()).Update(ctx, cm, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) {
+			return nil
+		}
+		return err
+	}
+	pods, err := c.kubeClient.CoreV1().Pods(status.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{apitypes.LabelWorkflow: status.Id}).String(),
+	})
+	if err != nil {
+		return err
+	}
+	podMap := make(map[string]*corev1.Pod)
+	for i := range pods.Items {
+		podMap[pods.Items[i].GetName()] = &pods.Items[i]
+	}
+
+	changed := false
+	for i := range status.Steps {
+		stepStatus := &status.Steps[i]
+		if stepStatus.Phase != apitypes.StepPhaseRunning {
+			continue
+		}
+		before := *stepStatus
+		pod := podMap[stepStatus.Worker]
+		var cm *corev1.ConfigMap
+		if pod == nil && c.queueEnabled {
+			pod, cm, err = getQueuedPod(ctx, c.kubeClient, status.Namespace, stepStatus.Worker)
+			if err != nil && !k8serrors.IsNotFound(err) {
+				return err
+			}
+		}
+		if cm != nil {
+			adapter.UpdateQueuedStepStatus(stepStatus, cm)
+		} else {
+			adapter.UpdateStepStatus(stepStatus, pod)
+		}
+		changed = changed || before.Phase != stepStatus.Phase || before.Reason != stepStatus.Reason ||
+			before.Message != stepStatus.Message || (before.StartTime == nil) != (stepStatus.StartTime == nil)
+	}
+	// Skipping a step may make its dependents ready, so evaluate until nothing changes.
+	for progress := true; progress; {
+		progress = false
+		for i := range status.Steps {
+			stepStatus := &status.Steps[i]
+			if stepStatus.Phase != apitypes.StepPhasePending {
+				continue
+			}
+			ready, run := adapter.EvaluateStep(&wf.Steps[i], status)
+			if !ready {
+				continue
+			}
+			progress, changed = true, true
+			if !run {
+				now := time.Now()
+				stepStatus.Phase, stepStatus.FinishTime = apitypes.StepPhaseSkipped, &now
+				continue
+			}
+			if err = c.startStep(ctx, status, &wf.Steps[i], stepStatus); err != nil {
+				return err
+			}
+		}
+	}
+	changed = adapter.UpdateWorkflowPhase(status) || changed
+	if !changed {
+		return nil
+	}
+	if err = adapter.SetConfigMapWorkflowStatus(cm, status); err != nil {
+		return err
+	}
+	// A conflict means another replica or request has changed the workflow. It's synced again in next period.
+	_, err = c.kubeClient.CoreV1().ConfigMaps(cm.GetNamespace()).Update(ctx, cm, metav1.UpdateOptions{})
+	if k8serrors.IsConflict(err) {
+		return nil
+	}
+
+	return err
+}
+
+// startStep creates or queues the Pod of the current attempt of step. The Pod which already exists is created by
+// another replica and is adopted.
+func (c *WorkflowController) startStep(ctx context.Context, status *v1types.WorkflowStatus,
+	step *v1types.WorkflowStep, stepStatus *v1types.StepStatus) error {
+	ctx = context.WithValue(ctx, apitypes.LogCtxID, uuid.New())
+	pod, err := adapter.TranslateStepToPod(ctx, status, step, stepStatus)
+	if err != nil {
+		now := time.Now()
+		stepStatus.Phase, stepStatus.FinishTime = apitypes.StepPhaseFailed, &now
+		stepStatus.Reason, stepStatus.Message = reasonInvalidSpec, err.Error()
+		return nil
+	}
+	if err = createPod(ctx, c.kubeClient, pod, c.queueEnabled); err != nil {
+		return err
+	}
+	logger.InfoFields("Successfully start workflow step", logger.Fields{
+		apitypes.LogWorkerName:      pod.GetName(),
+		apitypes.LogWorkerNamespace: pod.GetNamespace(),
+	})
+	stepStatus.Phase, stepStatus.Worker = apitypes.StepPhaseRunning, pod.GetName()
+
+	return nil
+}
+
+// cleanup deletes the workflows finished more than workflowTTL ago. The workflow retried meanwhile is kept since
+// the deletion is conditioned on its resource version.
+func (c *WorkflowController) cleanup(ctx context.Context, namespace string) {
+	cms, err := c.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: finishedWorkflowSelector,
+	})
+	if err != nil {
+		logger.ErrorFields("Fail to list finished workflows", logger.Fields{
+			apitypes.LogWorkerNamespace: namespace,
+			logger.ERROR:                err,
+		})
+		return
+	}
+	now := time.Now()
+	for i := range cms.Items {
+		cm := &cms.Items[i]
+		_, status, err := adapter.TranslateConfigMapToWorkflow(cm)
+		if err != nil || status.FinishTime == nil || now.Sub(*status.FinishTime) < workflowTTL {
+			continue
+		}
+		resourceVersion := cm.GetResourceVersion()
+		err = c.kubeClient.CoreV1().ConfigMaps(namespace).Delete(ctx, cm.GetName(), metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: &resourceVersion},
+		})
+		if err == nil {
+			logger.InfoFields("Successfully delete expired workflow", logger.Fields{
+				apitypes.LogWorkerName:      cm.GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+			})
+		} else if !k8serrors.IsNotFound(err) && !k8serrors.IsConflict(err) {
+			logger.ErrorFields("Fail to delete expired workflow", logger.Fields{
+				apitypes.LogWorkerName:      cm.GetName(),
+				apitypes.LogWorkerNamespace: namespace,
+				logger.ERROR:                err,
+			})
+		}
+	}
+}